@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/instructhub/backend/app/migrations"
+	"github.com/instructhub/backend/pkg/cache"
+	"github.com/instructhub/backend/pkg/config"
+	"github.com/instructhub/backend/pkg/content"
+	db "github.com/instructhub/backend/pkg/database"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/lockout"
+	"github.com/instructhub/backend/pkg/logger"
+	"github.com/instructhub/backend/pkg/mailer"
+	oauth "github.com/instructhub/backend/pkg/oauth"
+	"github.com/instructhub/backend/pkg/ratelimit"
+	"github.com/instructhub/backend/pkg/storage"
+	"github.com/instructhub/backend/pkg/tracing"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// App holds the configuration and the services the handlers, workers and commands depend on,
+// they can be replaced with fakes when building it by hand
+type App struct {
+	Config  *config.Config
+	DB      *gorm.DB
+	Cache   *redis.Client
+	Limiter *redis.Client
+	Storage storage.BlobStore
+	Content content.ContentStore
+	Mailer  mailer.Mailer
+	Logger  *zap.Logger
+	// RateLimiter counts the requests of the rate limited routes, nil turns the limits off
+	RateLimiter ratelimit.Limiter
+	// Lockout counts the failed logins
+	Lockout *lockout.Guard
+
+	StartedAt time.Time
+	// draining is set once the shutdown started, the readiness probe fails from then on
+	draining atomic.Bool
+	// stopTracing flushes the spans not exported yet
+	stopTracing func(context.Context) error
+}
+
+// tracingFlushTimeout bounds sending the last spans when closing
+const tracingFlushTimeout = 5 * time.Second
+
+// New connects to every service of the configuration
+func New(cfg *config.Config) (*App, error) {
+	utils.LoadVariables(cfg)
+	if err := utils.RegisterValidators(); err != nil {
+		return nil, err
+	}
+	encryption.SetupSnowflake(cfg.Server.MachineID)
+	if err := encryption.SetJwtSecretKey(cfg.Auth.JWTSecretKey); err != nil {
+		return nil, err
+	}
+	if err := encryption.SetSecretKey(cfg.Auth.MFAEncryptionKey); err != nil {
+		return nil, err
+	}
+	encryption.SetHashParams(cfg.Argon2.Memory, cfg.Argon2.Iterations, cfg.Argon2.Parallelism)
+	oauth.UseProviders(cfg.OAuth, cfg.BackendURL())
+
+	log, err := logger.New(cfg.Server.GinMode == "debug")
+	if err != nil {
+		return nil, err
+	}
+	app := &App{Config: cfg, Logger: log, StartedAt: time.Now()}
+
+	// Set up before connecting so the clients are traced
+	app.stopTracing, err = tracing.Setup(context.Background(), tracing.Options{
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+		ServiceName:  cfg.Tracing.ServiceName,
+		Version:      cfg.Server.Version,
+	})
+	if err != nil {
+		log.Sync()
+		return nil, fmt.Errorf("error setting up tracing: %w", err)
+	}
+
+	if err := app.connect(); err != nil {
+		app.Close()
+		return nil, err
+	}
+	return app, nil
+}
+
+// connect opens the connections of the App, the ones already opened are kept on error so Close can release them
+func (app *App) connect() error {
+	cfg := app.Config
+
+	var err error
+	if app.DB, err = OpenDatabase(cfg); err != nil {
+		return err
+	}
+	app.Logger.Info("Successfully connected to PostgreSQL")
+	if cfg.Database.AutoMigrate {
+		if err := app.migrate(); err != nil {
+			return err
+		}
+	}
+
+	addr := fmt.Sprintf("%s:%s", cfg.Cache.Host, cfg.Cache.Port)
+	if app.Cache, err = cache.Connect(addr, cfg.Cache.Password, cache.NormalDB); err != nil {
+		return err
+	}
+	if app.Limiter, err = cache.Connect(addr, cfg.Cache.Password, cache.LimiterDB); err != nil {
+		return err
+	}
+	switch cfg.RateLimit.Store {
+	case "redis":
+		app.RateLimiter = ratelimit.NewRedisLimiter(app.Limiter)
+	case "memory":
+		app.RateLimiter = ratelimit.NewMemoryLimiter()
+	}
+	app.Lockout = lockout.NewGuard(app.Limiter, lockout.Policy{
+		FreeAttempts:  cfg.Auth.LoginFreeAttempts,
+		MaxAttempts:   cfg.Auth.LoginMaxAttempts,
+		LockDuration:  cfg.Auth.LoginLockDuration,
+		Window:        cfg.Auth.LoginFailureWindow,
+		MaxIPAttempts: cfg.Auth.LoginMaxIPAttempts,
+	})
+
+	// The stores and the mailer are instrumented for the metrics
+	blobStore, err := newBlobStore(cfg, app.Logger)
+	if err != nil {
+		return fmt.Errorf("error creating blob store: %w", err)
+	}
+	app.Storage = storage.Instrument(blobStore, cfg.Storage.Type)
+	contentStore, err := newContentStore(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating content store: %w", err)
+	}
+	app.Content = content.Instrument(contentStore, cfg.Content.Store)
+
+	app.Mailer = mailer.Instrument(mailer.NewSMTPMailer(mailer.SMTPOptions{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Key:      cfg.SMTP.Key,
+		From:     cfg.SMTP.From,
+	}))
+	return nil
+}
+
+// OpenDatabase connects to the PostgreSQL database of the configuration without the other services
+func OpenDatabase(cfg *config.Config) (*gorm.DB, error) {
+	return db.Connect(db.Config{
+		Host:     cfg.Database.Host,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		Port:     cfg.Database.Port,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+}
+
+// migrate applies the pending migrations
+func (app *App) migrate() error {
+	migrator, err := migrations.NewMigrator(app.DB)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(0)
+	for _, migration := range applied {
+		app.Logger.Info("Applied migration", zap.Uint64("version", migration.Version), zap.String("name", migration.Name))
+	}
+	return err
+}
+
+// Drain marks the App as shutting down so the load balancer stops sending requests
+func (app *App) Drain() {
+	app.draining.Store(true)
+}
+
+// Draining reports whether the shutdown started
+func (app *App) Draining() bool {
+	return app.draining.Load()
+}
+
+// Close releases the connections of the App, the stores first since they may still use the others
+func (app *App) Close() {
+	for _, store := range []interface{}{app.Content, app.Storage} {
+		if closer, ok := store.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				app.Logger.Error("Failed to close store", zap.Error(err))
+			}
+		}
+	}
+	if app.Limiter != nil {
+		app.Limiter.Close()
+	}
+	if app.Cache != nil {
+		app.Cache.Close()
+	}
+	if app.DB != nil {
+		if err := db.Close(app.DB); err != nil {
+			app.Logger.Error("Failed to close PostgreSQL connection", zap.Error(err))
+		} else {
+			app.Logger.Info("Successfully disconnected to PostgreSQL")
+		}
+	}
+	if app.stopTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := app.stopTracing(ctx); err != nil {
+			app.Logger.Error("Failed to flush the spans", zap.Error(err))
+		}
+	}
+	app.Logger.Sync()
+}
+
+// newContentStore creates the content store chosen by CONTENT_STORE
+func newContentStore(cfg *config.Config) (content.ContentStore, error) {
+	switch cfg.Content.Store {
+	case "gitea":
+		return content.NewGiteaStore(cfg.Gitea.URL, cfg.Gitea.Token, cfg.Gitea.OrgName)
+	case "local":
+		return content.NewLocalStore(cfg.Content.Path)
+	default:
+		return nil, fmt.Errorf("unknown content store %q", cfg.Content.Store)
+	}
+}
+
+// newBlobStore creates the blob store chosen by STORAGE
+func newBlobStore(cfg *config.Config, log *zap.Logger) (storage.BlobStore, error) {
+	switch cfg.Storage.Type {
+	case "s3":
+		return storage.NewS3Store(storage.S3Options{
+			Endpoint:     cfg.S3.Endpoint,
+			AccessKeyID:  cfg.S3.AccessKeyID,
+			SecretKey:    cfg.S3.SecretKey,
+			Bucket:       cfg.S3.StaticBucket,
+			BaseURL:      cfg.S3.StaticBaseURL,
+			PathStyle:    cfg.S3.PathStyle,
+			TLSVerify:    cfg.S3.TLSVerify,
+			CreateBucket: cfg.S3.CreateBucket,
+		}, log)
+	case "local":
+		return storage.NewLocalStore(cfg.Storage.Path, cfg.BackendURL()+storage.LocalRoute)
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage.Type)
+	}
+}
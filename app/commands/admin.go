@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/instructhub/backend/app/controllers"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/encryption"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+)
+
+const adminUsage = "usage: admin create --email email --username name [--display-name name] | promote [--revoke] user"
+
+// runAdmin creates admin users or changes the admin rights of a user
+func runAdmin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(adminUsage)
+	}
+
+	flags := flag.NewFlagSet("admin "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "create":
+		email := flags.String("email", "", "email of the admin")
+		username := flags.String("username", "", "username of the admin")
+		displayName := flags.String("display-name", "", "display name of the admin, the username by default")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *displayName == "" {
+			*displayName = *username
+		}
+		return createAdmin(*email, *username, *displayName)
+	case "promote":
+		revoke := flags.Bool("revoke", false, "remove the admin rights instead")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf(adminUsage)
+		}
+		return promoteAdmin(flags.Arg(0), !*revoke)
+	default:
+		return fmt.Errorf(adminUsage)
+	}
+}
+
+// createAdmin creates a verified admin user with a password read from the terminal
+func createAdmin(email, username, displayName string) error {
+	app, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	// Validate like the signup does
+	request := controllers.EmailAuthRequest{Username: username, DisplayName: displayName, Email: email, Password: password}
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return err
+	}
+
+	_, result := queries.GetUserQueueByEmail(app.DB, email)
+	if result.Error == nil {
+		return fmt.Errorf("email %s is already used, promote the user instead", email)
+	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return result.Error
+	}
+	_, result = queries.GetUserQueueByUsername(app.DB, username)
+	if result.Error == nil {
+		return fmt.Errorf("username %s is already used, promote the user instead", username)
+	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return result.Error
+	}
+
+	hashedPassword, err := encryption.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user := models.User{
+		ID:          encryption.GenerateID(),
+		DisplayName: displayName,
+		Username:    username,
+		Email:       email,
+		Password:    hashedPassword,
+		Verify:      true,
+		IsAdmin:     true,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if result := queries.CreateUserQueue(app.DB, user); result.Error != nil {
+		return result.Error
+	}
+
+	fmt.Printf("Created admin %s (%d)\n", user.Username, user.ID)
+	return nil
+}
+
+// promoteAdmin grants or removes the admin rights of a user
+func promoteAdmin(identifier string, isAdmin bool) error {
+	app, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	user, err := findUser(app.DB, identifier)
+	if err != nil {
+		return err
+	}
+	if result := queries.UpdateUserAdminStatus(app.DB, user.ID, isAdmin); result.Error != nil {
+		return result.Error
+	}
+
+	if isAdmin {
+		fmt.Printf("%s (%d) is now an admin\n", user.Username, user.ID)
+	} else {
+		fmt.Printf("%s (%d) is no longer an admin\n", user.Username, user.ID)
+	}
+	return nil
+}
+
+// readPassword asks for the password twice on a terminal, or reads a line when the input is piped
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the password from the input: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(password) != string(repeated) {
+		return "", fmt.Errorf("the passwords don't match")
+	}
+	return string(password), nil
+}
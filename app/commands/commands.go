@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/config"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// command is a subcommand run instead of the server, args don't include its name
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"consistency": {usage: "consistency check|repair [flags]  Compare the database with Gitea", run: runConsistency},
+	"config":      {usage: "config print [--redacted] [--profile name]  Print the configuration", run: runConfig},
+	"migrate":     {usage: "migrate up|down|status|create [flags]  Apply, revert, list or create the database migrations", run: runMigrate},
+	"admin":       {usage: "admin create|promote  Create an admin user or give the admin rights to a user", run: runAdmin},
+	"user":        {usage: "user verify|revoke-sessions|resend-verification|unlock|disable-mfa user  Manage a user by ID, email or username", run: runUser},
+	"sessions":    {usage: "sessions purge  Delete the expired sessions", run: runSessions},
+	"course":      {usage: "course rebuild|dump courseID [flags]  Rebuild the modules and steps from the content store or dump a course", run: runCourse},
+}
+
+// Run runs the subcommand named by the first argument
+func Run(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], Usage())
+	}
+	return cmd.run(args[1:])
+}
+
+// Usage lists the available subcommands
+func Usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var usage strings.Builder
+	usage.WriteString("Commands:\n")
+	for _, name := range names {
+		usage.WriteString("  " + commands[name].usage + "\n")
+	}
+	return usage.String()
+}
+
+// openApp loads the configuration and connects to the services like the server does
+func openApp() (*app.App, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return app.New(cfg)
+}
+
+// findUser gets a user by ID, email or username
+func findUser(db *gorm.DB, identifier string) (models.User, error) {
+	var user models.User
+	var result *gorm.DB
+	if id, err := utils.StrToUint64(identifier); err == nil {
+		user, result = queries.GetUserQueueByID(db, id)
+	} else if strings.Contains(identifier, "@") {
+		user, result = queries.GetUserQueueByEmail(db, identifier)
+	} else {
+		user, result = queries.GetUserQueueByUsername(db, identifier)
+	}
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return user, fmt.Errorf("user %q not found", identifier)
+	}
+	return user, result.Error
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/instructhub/backend/pkg/config"
+)
+
+// runConfig prints the configuration the server would start with
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("usage: config print [--redacted] [--profile name]")
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", false, "hide the passwords, keys and tokens")
+	profile := flags.String("profile", "", "print this profile instead of the one picked by APP_ENV")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var cfg *config.Config
+	var err error
+	if *profile != "" {
+		cfg, err = config.LoadProfile(*profile)
+	} else {
+		cfg, err = config.Load()
+	}
+
+	// An invalid configuration is still printed to help fixing it
+	var validationErr *config.ValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		return err
+	}
+	if printErr := cfg.Print(os.Stdout, *redacted); printErr != nil {
+		return printErr
+	}
+	return err
+}
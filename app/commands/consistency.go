@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/instructhub/backend/app/consistency"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// runConsistency checks, and optionally repairs, the consistency between the database and Gitea
+func runConsistency(args []string) error {
+	if len(args) == 0 || (args[0] != "check" && args[0] != "repair") {
+		return fmt.Errorf("usage: consistency check|repair [flags]")
+	}
+	repair := args[0] == "repair"
+
+	flags := flag.NewFlagSet("consistency "+args[0], flag.ContinueOnError)
+	courseID := flags.String("course", "", "only check the course with this ID")
+	structure := flags.Bool("structure", false, "rebuild the modules and steps in the database from course_data.json")
+	orphanFiles := flags.Bool("orphan-files", false, "delete the files no step references")
+	missingFiles := flags.Bool("missing-files", false, "create an empty file for the steps without one")
+	revisions := flags.Bool("revisions", false, "merge the pull requests of revisions marked as merged")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	options := consistency.RepairOptions{}
+	if repair {
+		// Repair everything when nothing is picked
+		all := !*structure && !*orphanFiles && !*missingFiles && !*revisions
+		options = consistency.RepairOptions{
+			RebuildStructure:   all || *structure,
+			DeleteOrphanFiles:  all || *orphanFiles,
+			CreateMissingFiles: all || *missingFiles,
+			RemergeRevisions:   all || *revisions,
+		}
+	}
+
+	app, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	checker := consistency.NewChecker(app.DB, app.Content)
+
+	var report consistency.Report
+	if *courseID != "" {
+		id, parseErr := utils.StrToUint64(*courseID)
+		if parseErr != nil {
+			return fmt.Errorf("invalid course ID %q", *courseID)
+		}
+		report, err = checker.RunCourse(context.Background(), id, options)
+	} else {
+		report, err = checker.Run(context.Background(), options)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := printJSON(os.Stdout, report); err != nil {
+		return err
+	}
+
+	if unrepaired := report.Unrepaired(); unrepaired > 0 {
+		return fmt.Errorf("%d issues left", unrepaired)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/instructhub/backend/app/consistency"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const courseUsage = "usage: course rebuild courseID | dump [--ref branch] [--out file] courseID"
+
+// courseDump is a course with its members and the files of its repository
+type courseDump struct {
+	Course      models.Course             `json:"course"`
+	LandingPage *models.CourseLandingPage `json:"landing_page,omitempty"`
+	Members     []models.CourseMember     `json:"members"`
+	Ref         string                    `json:"ref"`
+	Commit      string                    `json:"commit"`
+	Files       map[string]string         `json:"files"`
+}
+
+// runCourse rebuilds the structure of a course from the content store or dumps it
+func runCourse(args []string) error {
+	if len(args) == 0 || (args[0] != "rebuild" && args[0] != "dump") {
+		return fmt.Errorf(courseUsage)
+	}
+
+	flags := flag.NewFlagSet("course "+args[0], flag.ContinueOnError)
+	ref := flags.String("ref", "en", "branch of the dumped files")
+	out := flags.String("out", "", "write the dump to this file instead of the standard output")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf(courseUsage)
+	}
+	courseID, err := utils.StrToUint64(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid course ID %q", flags.Arg(0))
+	}
+
+	app, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	if _, result := queries.GetCourseInformation(app.DB, courseID); errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("course %d not found", courseID)
+	} else if result.Error != nil {
+		return result.Error
+	}
+
+	if args[0] == "rebuild" {
+		checker := consistency.NewChecker(app.DB, app.Content)
+		report, err := checker.RunCourse(context.Background(), courseID, consistency.RepairOptions{RebuildStructure: true})
+		if err != nil {
+			return err
+		}
+		return printJSON(os.Stdout, report)
+	}
+
+	dump, err := dumpCourse(context.Background(), app.DB, app.Content, courseID, *ref)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return printJSON(os.Stdout, dump)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return printJSON(file, dump)
+}
+
+// dumpCourse reads the course from the database and its files at ref
+func dumpCourse(ctx context.Context, db *gorm.DB, store content.ContentStore, courseID uint64, ref string) (courseDump, error) {
+	dump := courseDump{Ref: ref, Files: map[string]string{}}
+
+	var result *gorm.DB
+	if dump.Course, result = queries.GetCourseWithDetails(db, courseID); result.Error != nil {
+		return dump, result.Error
+	}
+	landingPage, result := queries.GetCourseLandingPage(db, courseID)
+	if result.Error == nil {
+		dump.LandingPage = &landingPage
+	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return dump, result.Error
+	}
+	if dump.Members, result = queries.GetCourseMembers(db, courseID); result.Error != nil {
+		return dump, result.Error
+	}
+	// Keep the password hashes out of the dump
+	for _, member := range dump.Members {
+		if member.User != nil {
+			member.User.Password = ""
+		}
+	}
+
+	repo := utils.Uint64ToStr(courseID)
+	commit, err := store.GetBranch(ctx, repo, ref)
+	if err != nil {
+		return dump, fmt.Errorf("failed to read branch %s: %w", ref, err)
+	}
+	dump.Commit = commit.SHA
+	files, err := store.ListFiles(ctx, repo, commit.SHA)
+	if err != nil {
+		return dump, fmt.Errorf("failed to list files: %w", err)
+	}
+	for _, file := range files {
+		data, err := store.ReadFile(ctx, repo, commit.SHA, file.Path)
+		if err != nil {
+			return dump, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+		dump.Files[file.Path] = string(data)
+	}
+	return dump, nil
+}
+
+func printJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/migrations"
+	"github.com/instructhub/backend/pkg/config"
+	db "github.com/instructhub/backend/pkg/database"
+)
+
+const migrateUsage = "usage: migrate up [--to version] | down [--steps n] | status | create name"
+
+// runMigrate applies, reverts, lists or creates the database migrations
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "up":
+		to := flags.Uint64("to", 0, "stop after this version instead of applying every pending migration")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		return withMigrator(func(migrator *migrations.Migrator) error {
+			applied, err := migrator.Up(*to)
+			printMigrations("Applied", applied)
+			return err
+		})
+	case "down":
+		steps := flags.Int("steps", 1, "how many migrations to revert")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		return withMigrator(func(migrator *migrations.Migrator) error {
+			reverted, err := migrator.Down(*steps)
+			printMigrations("Reverted", reverted)
+			return err
+		})
+	case "status":
+		return withMigrator(printStatus)
+	case "create":
+		dir := flags.String("dir", migrations.Dir, "directory of the migration files")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: migrate create [--dir path] name")
+		}
+		paths, err := migrations.Create(*dir, flags.Arg(0))
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return err
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+}
+
+// withMigrator connects to the database only, so the migrations aren't applied on startup
+func withMigrator(run func(migrator *migrations.Migrator) error) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	database, err := app.OpenDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close(database)
+
+	migrator, err := migrations.NewMigrator(database)
+	if err != nil {
+		return err
+	}
+	return run(migrator)
+}
+
+func printMigrations(action string, done []migrations.Migration) {
+	if len(done) == 0 {
+		fmt.Println("Nothing to do")
+	}
+	for _, migration := range done {
+		fmt.Printf("%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+func printStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if status.Missing {
+			appliedAt += " (unknown to this version)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
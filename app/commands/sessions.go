@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/instructhub/backend/app/queries"
+)
+
+// runSessions deletes the expired sessions
+func runSessions(args []string) error {
+	if len(args) != 1 || args[0] != "purge" {
+		return fmt.Errorf("usage: sessions purge")
+	}
+
+	app, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	result := queries.DeleteExpiredSessions(app.DB, time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	fmt.Printf("Deleted %d expired sessions\n", result.RowsAffected)
+	return nil
+}
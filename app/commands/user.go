@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/instructhub/backend/app/controllers"
+	"github.com/instructhub/backend/app/queries"
+	"gorm.io/gorm"
+)
+
+const userUsage = "usage: user verify|revoke-sessions|resend-verification|unlock|disable-mfa user"
+
+// runUser verifies the email, signs out, re-sends the verification email, unlocks the login or turns the
+// two-factor authentication off for a user
+func runUser(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf(userUsage)
+	}
+	action, identifier := args[0], args[1]
+	if action != "verify" && action != "revoke-sessions" && action != "resend-verification" && action != "unlock" && action != "disable-mfa" {
+		return fmt.Errorf(userUsage)
+	}
+
+	app, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	user, err := findUser(app.DB, identifier)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "verify":
+		if result := queries.UpdateUserVerifyStatus(app.DB, user.ID, true); result.Error != nil {
+			return result.Error
+		}
+		fmt.Printf("Verified the email of %s (%d)\n", user.Username, user.ID)
+	case "revoke-sessions":
+		result := queries.DeleteUserSessions(app.DB, user.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		// The access tokens already issued stay valid until they expire
+		fmt.Printf("Revoked %d sessions of %s (%d), access tokens expire within %s\n", result.RowsAffected, user.Username, user.ID, app.Config.Auth.AccessTokenExpires)
+	case "resend-verification":
+		if user.Verify {
+			return fmt.Errorf("%s is already verified", user.Username)
+		}
+		if err := controllers.NewHandler(app).SendVerificationEmail(context.Background(), user); err != nil {
+			return err
+		}
+		fmt.Printf("Sent a verification email to %s\n", user.Email)
+	case "unlock":
+		if err := app.Lockout.Unlock(context.Background(), user.Email); err != nil {
+			return err
+		}
+		fmt.Printf("Unlocked the login of %s (%d)\n", user.Username, user.ID)
+	case "disable-mfa":
+		// For the users who lost their authenticator and their recovery codes
+		err := app.DB.Transaction(func(tx *gorm.DB) error {
+			if err := queries.UpdateUserTOTP(tx, user.ID, "", false).Error; err != nil {
+				return err
+			}
+			return queries.DeleteUserRecoveryCodes(tx, user.ID).Error
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Disabled the two-factor authentication of %s (%d)\n", user.Username, user.ID)
+	}
+	return nil
+}
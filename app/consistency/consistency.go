@@ -0,0 +1,478 @@
+package consistency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// courseBatchSize is how many courses are loaded at once when walking every course
+const courseBatchSize = 100
+
+// repoFiles are the files a course repository may hold besides the step files
+var repoFiles = map[string]bool{
+	models.CourseDataFile: true,
+	"README.md":           true,
+	"LICENSE":             true,
+	".gitignore":          true,
+}
+
+type IssueKind string
+
+const (
+	IssueMissingRepo       IssueKind = "missing_repo"
+	IssueOrphanRepo        IssueKind = "orphan_repo"
+	IssueStructureMismatch IssueKind = "structure_mismatch"
+	IssueMissingFile       IssueKind = "missing_file"
+	IssueOrphanFile        IssueKind = "orphan_file"
+	IssueUnmergedRevision  IssueKind = "unmerged_revision"
+	// The course couldn't be checked, the message holds the error
+	IssueCheckFailed IssueKind = "check_failed"
+)
+
+// Issue is a difference found between the database and Gitea
+type Issue struct {
+	Kind     IssueKind `json:"kind"`
+	CourseID uint64    `json:"course_id,string,omitempty"`
+	Target   string    `json:"target,omitempty"`
+	Message  string    `json:"message"`
+	Repaired bool      `json:"repaired"`
+}
+
+// Report is the result of a consistency check
+type Report struct {
+	CheckedCourses int       `json:"checked_courses"`
+	Issues         []Issue   `json:"issues"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+}
+
+// Unrepaired counts the issues left after the check
+func (r Report) Unrepaired() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			count++
+		}
+	}
+	return count
+}
+
+// RepairOptions selects which issues are repaired, orphan repositories are only ever reported
+type RepairOptions struct {
+	// Rebuild the modules and steps in the database from course_data.json
+	RebuildStructure bool
+	// Delete the files no step references
+	DeleteOrphanFiles bool
+	// Create an empty file for the steps without one
+	CreateMissingFiles bool
+	// Merge the pull requests of revisions marked as merged
+	RemergeRevisions bool
+}
+
+// Checker compares the database with the content store
+type Checker struct {
+	db    *gorm.DB
+	store content.ContentStore
+}
+
+// NewChecker creates a checker of the database and the content store
+func NewChecker(db *gorm.DB, store content.ContentStore) *Checker {
+	return &Checker{db: db, store: store}
+}
+
+// Run checks every course and the repositories of the Gitea organization
+func (checker *Checker) Run(ctx context.Context, options RepairOptions) (Report, error) {
+	report := Report{Issues: []Issue{}, StartedAt: time.Now()}
+
+	courseIDs := map[string]bool{}
+	var lastID uint64
+	for {
+		ids, result := queries.GetCourseIDsAfter(checker.db.WithContext(ctx), lastID, courseBatchSize)
+		if result.Error != nil {
+			return report, result.Error
+		}
+		for _, courseID := range ids {
+			courseIDs[utils.Uint64ToStr(courseID)] = true
+			// A course that can't be checked doesn't stop the walk, the other courses are still checked
+			issues, err := checker.checkCourse(ctx, courseID, options)
+			if err != nil {
+				issues = []Issue{{Kind: IssueCheckFailed, CourseID: courseID, Target: utils.Uint64ToStr(courseID), Message: err.Error()}}
+			}
+			report.Issues = append(report.Issues, issues...)
+			report.CheckedCourses++
+		}
+		if len(ids) < courseBatchSize {
+			break
+		}
+		lastID = ids[len(ids)-1]
+	}
+
+	orphanRepos, err := checker.findOrphanRepos(ctx, courseIDs)
+	if err != nil {
+		return report, err
+	}
+	report.Issues = append(report.Issues, orphanRepos...)
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// RunCourse checks a single course
+func (checker *Checker) RunCourse(ctx context.Context, courseID uint64, options RepairOptions) (Report, error) {
+	report := Report{Issues: []Issue{}, StartedAt: time.Now()}
+
+	if _, result := queries.GetCourseInformation(checker.db.WithContext(ctx), courseID); result.Error != nil {
+		return report, result.Error
+	}
+
+	issues, err := checker.checkCourse(ctx, courseID, options)
+	if err != nil {
+		return report, err
+	}
+	report.Issues = issues
+	report.CheckedCourses = 1
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// checkCourse compares the database and the default branch of one course
+func (checker *Checker) checkCourse(ctx context.Context, courseID uint64, options RepairOptions) ([]Issue, error) {
+	repoName := utils.Uint64ToStr(courseID)
+
+	exists, err := checker.store.RepoExists(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		// The repository of a new course is created by the outbox worker, it may not be there yet
+		pending, result := queries.IsOutboxEventPending(checker.db.WithContext(ctx), models.OutboxIdempotencyKey(models.OutboxCreateCourseRepo, repoName))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if pending {
+			return []Issue{}, nil
+		}
+		return []Issue{{Kind: IssueMissingRepo, CourseID: courseID, Target: repoName, Message: "Course has no repository"}}, nil
+	}
+
+	data, err := checker.fetchCourseData(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+	modules, result := queries.GetAllCourseModules(checker.db.WithContext(ctx), courseID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	issues := compareStructure(courseID, data, modules)
+	if len(issues) > 0 && options.RebuildStructure {
+		if err := checker.rebuildStructure(ctx, courseID, data, modules); err != nil {
+			return nil, err
+		}
+		markRepaired(issues)
+	}
+
+	fileIssues, err := checker.checkFiles(ctx, courseID, data, options)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, fileIssues...)
+
+	revisionIssues, err := checker.checkMergedRevisions(ctx, courseID, options)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, revisionIssues...), nil
+}
+
+// compareStructure reports the modules and steps that differ between course_data.json and the database
+func compareStructure(courseID uint64, data models.CourseData, modules []models.CourseModule) []Issue {
+	issues := []Issue{}
+	mismatch := func(target, format string, args ...interface{}) {
+		issues = append(issues, Issue{Kind: IssueStructureMismatch, CourseID: courseID, Target: target, Message: fmt.Sprintf(format, args...)})
+	}
+
+	dbModules := map[string]models.CourseModule{}
+	dbSteps := map[string]models.CourseStep{}
+	for _, module := range modules {
+		if module.Active == nil || !*module.Active {
+			continue
+		}
+		dbModules[utils.Uint64ToStr(module.ID)] = module
+		if module.CourseSteps == nil {
+			continue
+		}
+		for _, step := range *module.CourseSteps {
+			if step.Active != nil && *step.Active {
+				dbSteps[utils.Uint64ToStr(step.ID)] = step
+			}
+		}
+	}
+
+	gitModules := map[string]bool{}
+	gitSteps := map[string]bool{}
+	for _, module := range data.Modules {
+		if module.ID == nil {
+			continue
+		}
+		moduleID := *module.ID
+		gitModules[moduleID] = true
+
+		dbModule, ok := dbModules[moduleID]
+		if !ok {
+			mismatch(moduleID, "Module %q is missing in the database", module.Name)
+		} else if dbModule.Name != module.Name || dbModule.Position != module.Position {
+			mismatch(moduleID, "Module %q differs in the database", module.Name)
+		}
+
+		for _, step := range module.CourseSteps {
+			if step.ID == nil {
+				continue
+			}
+			stepID := *step.ID
+			gitSteps[stepID] = true
+
+			dbStep, ok := dbSteps[stepID]
+			if !ok {
+				mismatch(stepID, "Step %q is missing in the database", step.Name)
+			} else if dbStep.Name != step.Name || dbStep.Position != step.Position || dbStep.Type != step.Type || utils.Uint64ToStr(dbStep.ModuleID) != moduleID {
+				mismatch(stepID, "Step %q differs in the database", step.Name)
+			}
+		}
+	}
+
+	for moduleID, module := range dbModules {
+		if !gitModules[moduleID] {
+			mismatch(moduleID, "Module %q is not in course_data.json", module.Name)
+		}
+	}
+	for stepID, step := range dbSteps {
+		if !gitSteps[stepID] {
+			mismatch(stepID, "Step %q is not in course_data.json", step.Name)
+		}
+	}
+
+	return issues
+}
+
+// rebuildStructure makes the modules and steps in the database match course_data.json
+func (checker *Checker) rebuildStructure(ctx context.Context, courseID uint64, data models.CourseData, modules []models.CourseModule) error {
+	dbModules := map[string]models.CourseModule{}
+	dbSteps := map[string]models.CourseStep{}
+	for _, module := range modules {
+		dbModules[utils.Uint64ToStr(module.ID)] = module
+		if module.CourseSteps == nil {
+			continue
+		}
+		for _, step := range *module.CourseSteps {
+			dbSteps[utils.Uint64ToStr(step.ID)] = step
+		}
+	}
+
+	saveModules := []models.CourseModule{}
+	saveSteps := []models.CourseStep{}
+	keptModules := map[uint64]bool{}
+	keptSteps := map[uint64]bool{}
+	now := time.Now()
+
+	for _, module := range data.Modules {
+		if module.ID == nil {
+			continue
+		}
+		dbModule, ok := dbModules[*module.ID]
+		if !ok {
+			dbModule = models.CourseModule{ID: utils.StrToUint64NoError(*module.ID), CourseID: courseID, CreatedAt: now}
+		}
+		dbModule.Position = module.Position
+		dbModule.Name = module.Name
+		dbModule.Active = utils.BoolPtr(true)
+		dbModule.UpdatedAt = now
+		dbModule.CourseSteps = nil
+		saveModules = append(saveModules, dbModule)
+		keptModules[dbModule.ID] = true
+
+		for _, step := range module.CourseSteps {
+			if step.ID == nil {
+				continue
+			}
+			dbStep, ok := dbSteps[*step.ID]
+			if !ok {
+				dbStep = models.CourseStep{ID: utils.StrToUint64NoError(*step.ID), CreatedAt: now}
+			}
+			dbStep.ModuleID = dbModule.ID
+			dbStep.Position = step.Position
+			dbStep.Name = step.Name
+			dbStep.Type = step.Type
+			dbStep.Active = utils.BoolPtr(true)
+			dbStep.UpdatedAt = now
+			saveSteps = append(saveSteps, dbStep)
+			keptSteps[dbStep.ID] = true
+		}
+	}
+
+	// Everything else is deactivated like a revision removing it would do
+	for _, module := range modules {
+		if !keptModules[module.ID] && module.Active != nil && *module.Active {
+			module.Active = utils.BoolPtr(false)
+			module.UpdatedAt = now
+			module.CourseSteps = nil
+			saveModules = append(saveModules, module)
+		}
+	}
+	for _, step := range dbSteps {
+		if !keptSteps[step.ID] && step.Active != nil && *step.Active {
+			step.Active = utils.BoolPtr(false)
+			step.UpdatedAt = now
+			saveSteps = append(saveSteps, step)
+		}
+	}
+
+	return checker.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(saveModules) > 0 {
+			if result := queries.SaveCourseModules(tx, saveModules); result.Error != nil {
+				return result.Error
+			}
+		}
+		if len(saveSteps) > 0 {
+			if result := queries.SaveCourseSteps(tx, saveSteps); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+}
+
+// checkFiles reports the step files missing from the repository and the files no step references
+func (checker *Checker) checkFiles(ctx context.Context, courseID uint64, data models.CourseData, options RepairOptions) ([]Issue, error) {
+	repoName := utils.Uint64ToStr(courseID)
+
+	repoFileInfos, err := checker.store.ListFiles(ctx, repoName, models.CourseDefaultBranch)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	for _, file := range repoFileInfos {
+		files[file.Path] = true
+	}
+
+	issues := []Issue{}
+	changes := []content.File{}
+
+	steps := map[string]bool{}
+	for _, module := range data.Modules {
+		for _, step := range module.CourseSteps {
+			if step.ID == nil {
+				continue
+			}
+			steps[*step.ID] = true
+			if !files[*step.ID] {
+				issues = append(issues, Issue{Kind: IssueMissingFile, CourseID: courseID, Target: *step.ID, Message: fmt.Sprintf("Step %q has no file", step.Name), Repaired: options.CreateMissingFiles})
+				if options.CreateMissingFiles {
+					changes = append(changes, content.File{Path: *step.ID, Content: []byte{}, Operation: content.OperationCreate})
+				}
+			}
+		}
+	}
+
+	for path := range files {
+		if steps[path] || repoFiles[path] {
+			continue
+		}
+		issues = append(issues, Issue{Kind: IssueOrphanFile, CourseID: courseID, Target: path, Message: "File is not referenced by any step", Repaired: options.DeleteOrphanFiles})
+		if options.DeleteOrphanFiles {
+			changes = append(changes, content.File{Path: path, Operation: content.OperationDelete})
+		}
+	}
+
+	if len(changes) > 0 {
+		_, err := checker.store.CommitFiles(ctx, repoName, content.CommitRequest{
+			Author:  content.Identity{Name: "InstructHub", Email: content.CommitEmail(0)},
+			Branch:  models.CourseDefaultBranch,
+			Files:   changes,
+			Message: "fix: Repair course files",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return issues, nil
+}
+
+// checkMergedRevisions reports the revisions marked as merged whose pull request was never merged
+func (checker *Checker) checkMergedRevisions(ctx context.Context, courseID uint64, options RepairOptions) ([]Issue, error) {
+	repoName := utils.Uint64ToStr(courseID)
+
+	revisions, result := queries.GetCourseRevisionsByStatus(checker.db.WithContext(ctx), courseID, models.RevisionMerged)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	issues := []Issue{}
+	for _, revision := range revisions {
+		changeRequest, err := checker.store.GetChangeRequest(ctx, repoName, int64(revision.PullRequestID))
+		if err != nil {
+			return nil, err
+		}
+		if changeRequest.Merged {
+			continue
+		}
+
+		issue := Issue{Kind: IssueUnmergedRevision, CourseID: courseID, Target: utils.Uint64ToStr(revision.ID), Message: fmt.Sprintf("Pull request %d is not merged", revision.PullRequestID)}
+		if options.RemergeRevisions {
+			if err := checker.store.MergeChangeRequest(ctx, repoName, int64(revision.PullRequestID)); err != nil {
+				return nil, err
+			}
+			issue.Repaired = true
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+// findOrphanRepos reports the repositories of the organization that belong to no course
+func (checker *Checker) findOrphanRepos(ctx context.Context, courseIDs map[string]bool) ([]Issue, error) {
+	repos, err := checker.store.ListRepos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []Issue{}
+	for _, repo := range repos {
+		if !courseIDs[repo] {
+			issues = append(issues, Issue{Kind: IssueOrphanRepo, Target: repo, Message: "Repository belongs to no course"})
+		}
+	}
+	return issues, nil
+}
+
+// fetchCourseData retrieves and parses course_data.json from the default branch, a missing file is an empty course
+func (checker *Checker) fetchCourseData(ctx context.Context, repoName string) (models.CourseData, error) {
+	var data models.CourseData
+
+	file, err := checker.store.ReadFile(ctx, repoName, models.CourseDefaultBranch, models.CourseDataFile)
+	if errors.Is(err, content.ErrNotFound) {
+		return data, nil
+	} else if err != nil || len(file) == 0 {
+		return data, err
+	}
+	err = json.Unmarshal(file, &data)
+	return data, err
+}
+
+// markRepaired marks every issue as repaired
+func markRepaired(issues []Issue) {
+	for i := range issues {
+		issues[i].Repaired = true
+	}
+}
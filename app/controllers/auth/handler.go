@@ -0,0 +1,13 @@
+package auth
+
+import "github.com/instructhub/backend/app"
+
+// Handler serves the authentication endpoints with the services of the App
+type Handler struct {
+	*app.App
+}
+
+// NewHandler creates the authentication handler
+func NewHandler(app *app.App) *Handler {
+	return &Handler{App: app}
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/app/queries"
-	"github.com/instructhub/backend/pkg/cache"
 	"github.com/instructhub/backend/pkg/encryption"
 	"github.com/instructhub/backend/pkg/utils"
 	"gorm.io/gorm"
@@ -23,7 +22,7 @@ type emailAuthRequest struct {
 }
 
 // For user using email signup
-func Signup(c *gin.Context) {
+func (h *Handler) Signup(c *gin.Context) {
 	var request emailAuthRequest
 
 	// Validate request body
@@ -33,7 +32,7 @@ func Signup(c *gin.Context) {
 	}
 
 	// Check if email already been used
-	_, result := queries.GetUserQueueByEmail(request.Email)
+	_, result := queries.GetUserQueueByEmail(h.DB.WithContext(c), request.Email)
 	if result.Error == nil {
 		utils.FullyResponse(c, 400, "Email already been used", utils.ErrEmailAlreadyUsed, nil)
 		return
@@ -43,7 +42,7 @@ func Signup(c *gin.Context) {
 	}
 
 	// Check if username already been used
-	_, result = queries.GetUserQueueByUsername(request.Username)
+	_, result = queries.GetUserQueueByUsername(h.DB.WithContext(c), request.Username)
 	if result.Error == nil {
 		utils.FullyResponse(c, 400, "Username already been used", utils.ErrUsernameAlreadyUsed, nil)
 		return
@@ -80,19 +79,19 @@ func Signup(c *gin.Context) {
 	}
 
 	// Send verification email
-	if err := sendVerificationEmail(user.Email, user.Username, verifyToken); err != nil {
+	if err := h.sendVerificationEmail(user.Email, user.Username, verifyToken); err != nil {
 		utils.ServerErrorResponse(c, 500, "Error sending verification email", utils.ErrSendEmail, err)
 		return
 	}
 
 	// Store the verification token in Redis
-	if err := cache.RedisClient.Set(c, verifyToken, user.ID, 15*time.Minute).Err(); err != nil {
+	if err := h.Cache.Set(c, verifyToken, user.ID, 15*time.Minute).Err(); err != nil {
 		utils.ServerErrorResponse(c, 500, "Error storing verification key", utils.ErrSaveData, err)
 		return
 	}
 
 	// Create user in the queue
-	result = queries.CreateUserQueue(user)
+	result = queries.CreateUserQueue(h.DB.WithContext(c), user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		utils.ServerErrorResponse(c, 500, "Error creating new user", utils.ErrSaveData, result.Error)
 		return
@@ -113,7 +112,7 @@ func hashUserPassword(password string) (string, error) {
 	return encryption.HashPassword(password)
 }
 
-func sendVerificationEmail(email, username, verifyToken string) error {
+func (h *Handler) sendVerificationEmail(email, username, verifyToken string) error {
 	data := struct {
 		VerifyURL string
 		UserName  string
@@ -133,7 +132,7 @@ func sendVerificationEmail(email, username, verifyToken string) error {
 		return err
 	}
 
-	return utils.SendEmail(email, "Verify your email", emailBody.String())
+	return h.Mailer.Send(email, "Verify your email", emailBody.String())
 }
 
 func generateEmailVerifyJWT(userID uint64) (string, error) {
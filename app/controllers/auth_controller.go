@@ -2,15 +2,18 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
+	"math"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/app/queries"
-	"github.com/instructhub/backend/pkg/cache"
 	"github.com/instructhub/backend/pkg/encryption"
-	"github.com/instructhub/backend/pkg/logger"
+	"github.com/instructhub/backend/pkg/metrics"
 	"github.com/instructhub/backend/pkg/utils"
 	"github.com/markbates/goth/gothic"
 	"github.com/redis/go-redis/v9"
@@ -27,7 +30,7 @@ type EmailAuthRequest struct {
 }
 
 // For user using email signup
-func Signup(c *gin.Context) {
+func (h *Handler) Signup(c *gin.Context) {
 	var request EmailAuthRequest
 
 	// Validate request body
@@ -37,7 +40,7 @@ func Signup(c *gin.Context) {
 	}
 
 	// Check if email already been used
-	_, result := queries.GetUserQueueByEmail(request.Email)
+	_, result := queries.GetUserQueueByEmail(h.DB.WithContext(c), request.Email)
 	if result.Error == nil {
 		utils.FullyResponse(c, 400, "Email already been used", utils.ErrEmailAlreadyUsed, nil)
 		return
@@ -47,7 +50,7 @@ func Signup(c *gin.Context) {
 	}
 
 	// Check if username already been used
-	_, result = queries.GetUserQueueByUsername(request.Username)
+	_, result = queries.GetUserQueueByUsername(h.DB.WithContext(c), request.Username)
 	if result.Error == nil {
 		utils.FullyResponse(c, 400, "Username already been used", utils.ErrUsernameAlreadyUsed, nil)
 		return
@@ -75,48 +78,19 @@ func Signup(c *gin.Context) {
 	}
 	user.Password = hashedPassword
 
-	// Generate verification token
-	verifyToken, err := encryption.GenerateRandomBase64String(512)
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error hash generate verification token", utils.ErrHashData, err)
-		return
-	}
-
-	type EmailData struct {
-		VerifyURL string
-		UserName  string
-	}
-	data := EmailData{
-		VerifyURL: utils.BackendURL + "/auth/email/verify/" + verifyToken,
-		UserName:  user.Username,
-	}
-	var emailBody bytes.Buffer
-	t := template.New("Email verification")
-	t, err = t.ParseFiles("template/email_verificaiton.html")
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error parse email template", utils.ErrParseFile, err)
-		return
-	}
-	t.ExecuteTemplate(&emailBody, "email_verificaiton.html", data)
-	err = utils.SendEmail(user.Email, "Verification your email", emailBody.String())
-	if err != nil {
+	// Send the verification email
+	if err := h.SendVerificationEmail(c, user); err != nil {
 		utils.ServerErrorResponse(c, 500, "Error send verification email", utils.ErrSendEmail, err)
 		return
 	}
 
-	// Store the verification key in Redis (with expiration)
-	err = cache.RedisClient.Set(c, verifyToken, user.ID, 15*time.Minute).Err()
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error storing verification key", utils.ErrSaveData, err)
-		return
-	}
-
 	// Create user in the queue
-	result = queries.CreateUserQueue(user)
+	result = queries.CreateUserQueue(h.DB.WithContext(c), user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		utils.ServerErrorResponse(c, 500, "Error create new user", utils.ErrSaveData, result.Error)
 		return
 	}
+	metrics.Signups.WithLabelValues("email").Inc()
 
 	// Set a verify pedding jwt cookie for the user
 	verifyPeddingTokenExpiresAt := time.Now().Add(time.Minute * 15)
@@ -135,8 +109,15 @@ type EmailLoginRequest struct {
 	Password string `json:"password" binding:"required,max=128,min=8"`
 }
 
-// For login with email
-func Login(c *gin.Context) {
+// dummyHash is compared with the passwords sent for the unknown emails, so they take as long as the known ones
+var dummyHash struct {
+	once sync.Once
+	hash string
+	err  error
+}
+
+// For login with email, the unknown emails and the wrong passwords get the same answer
+func (h *Handler) Login(c *gin.Context) {
 	var request EmailLoginRequest
 
 	// Validate request body
@@ -150,31 +131,51 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	var result *gorm.DB
-
-	user, result = queries.GetUserQueueByEmail(request.Email)
-	if result.Error == gorm.ErrRecordNotFound {
-		utils.FullyResponse(c, 400, "Invalid email", utils.ErrInvalidUsernameOrEmail, nil)
+	// Accounts and IPs with too many failures wait before trying again
+	status, err := h.Lockout.Check(c, request.Email, c.ClientIP())
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error checking login attempts", utils.ErrGetData, err)
 		return
-	} else if result.Error != nil {
-		utils.ServerErrorResponse(c, 500, "Error check email", utils.ErrGetData, result.Error)
+	}
+	if status.RetryAfter > 0 {
+		metrics.Logins.WithLabelValues("email", "throttled").Inc()
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+		utils.FullyResponse(c, 429, "Too many failed logins, try again later", utils.ErrTooManyLoginAttempts, nil)
 		return
 	}
 
-	if user.Password == "" {
-		utils.FullyResponse(c, 400, "Invalid password", utils.ErrInvalidPassword, nil)
+	user, result := queries.GetUserQueueByEmail(h.DB.WithContext(c), request.Email)
+	found := result.Error == nil
+	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+		utils.ServerErrorResponse(c, 500, "Error check email", utils.ErrGetData, result.Error)
 		return
 	}
 
-	match, err := encryption.ComparePasswordAndHash(request.Password, user.Password)
-	if err != nil || !match {
-		utils.FullyResponse(c, 400, "Invalid password", utils.ErrInvalidPassword, nil)
+	// The OAuth users have no password
+	hash := user.Password
+	if hash == "" {
+		dummyHash.once.Do(func() {
+			dummyHash.hash, dummyHash.err = encryption.HashPassword("dummy password")
+		})
+		if dummyHash.err != nil {
+			utils.ServerErrorResponse(c, 500, "Error hash passwsord", utils.ErrHashData, dummyHash.err)
+			return
+		}
+		hash = dummyHash.hash
+	}
+	match, err := encryption.ComparePasswordAndHash(request.Password, hash)
+	if err != nil || !match || user.Password == "" {
+		metrics.Logins.WithLabelValues("email", "failure").Inc()
+		h.loginFailed(c, user, found, request.Email)
 		return
 	}
+	if err := h.Lockout.Succeed(c, request.Email); err != nil {
+		c.Error(err)
+	}
 
 	type notVerify struct {
-		Verify bool `json:"verify"`
+		Verify      bool `json:"verify"`
+		MFARequired bool `json:"mfa_required,omitempty"`
 	}
 	if !user.Verify {
 		// Set a verify pedding jwt cookie for the user
@@ -185,23 +186,72 @@ func Login(c *gin.Context) {
 			return
 		}
 		c.SetCookie("verify_pedding", verifyPeddintToken, 15*60, "/", "", false, false)
+		metrics.Logins.WithLabelValues("email", "unverified").Inc()
 		utils.FullyResponse(c, 403, "Email not verify", utils.ErrEmailNotVerify, notVerify{
 			Verify: false,
 		})
 		return
 	}
 
-	err = utils.GenerateUserSession(c, user.ID)
+	// With the two-factor authentication the session starts after LoginMFA
+	mfaRequired, err := h.beginLogin(c, user)
 	if err != nil {
 		utils.ServerErrorResponse(c, 500, "Internal server error", utils.ErrGenerateSession, err)
 		return
 	}
+	if mfaRequired {
+		metrics.Logins.WithLabelValues("email", "mfa_required").Inc()
+		utils.FullyResponse(c, 200, "Two-factor code required", nil, notVerify{
+			Verify:      true,
+			MFARequired: true,
+		})
+		return
+	}
+	metrics.Logins.WithLabelValues("email", "success").Inc()
 
 	utils.FullyResponse(c, 200, "Login successful", nil, notVerify{
 		Verify: true,
 	})
 }
 
+// loginFailed counts the failure and tells the user by email when it locked the account
+func (h *Handler) loginFailed(c *gin.Context, user models.User, found bool, email string) {
+	locked, err := h.Lockout.Fail(c, email, c.ClientIP())
+	if err != nil {
+		c.Error(err)
+	}
+	if locked && found {
+		metrics.Logins.WithLabelValues("email", "locked").Inc()
+		if err := h.sendAccountLockedEmail(user); err != nil {
+			c.Error(err)
+		}
+	}
+	utils.FullyResponse(c, 400, "Invalid email or password", utils.ErrInvalidCredentials, nil)
+}
+
+// sendAccountLockedEmail tells the user their account is locked after too many failed logins
+func (h *Handler) sendAccountLockedEmail(user models.User) error {
+	data := struct {
+		UserName  string
+		Attempts  int
+		LockedFor string
+	}{
+		UserName:  user.Username,
+		Attempts:  h.Config.Auth.LoginMaxAttempts,
+		LockedFor: fmt.Sprintf("%d minutes", int(h.Config.Auth.LoginLockDuration.Minutes())),
+	}
+
+	var emailBody bytes.Buffer
+	t, err := template.New("Account locked").ParseFiles("template/account_locked.html")
+	if err != nil {
+		return err
+	}
+	if err := t.ExecuteTemplate(&emailBody, "account_locked.html", data); err != nil {
+		return err
+	}
+	return h.Mailer.Send(user.Email, "Your account was locked", emailBody.String())
+}
+
 // Call Oauth login with google github etc
 func OAuthHandler(c *gin.Context, cprovider string) {
 	q := c.Request.URL.Query()
@@ -224,7 +274,7 @@ func GetProviderFromString(provider string) (models.Provider, error) {
 }
 
 // OAuth callback handler for Google, GitHub, etc.
-func OAuthCallbackHandler(c *gin.Context, cprovider string) {
+func (h *Handler) OAuthCallbackHandler(c *gin.Context, cprovider string) {
 	// Add the provider to the query parameters to keep track of it
 	q := c.Request.URL.Query()
 	q.Add("provider", cprovider)
@@ -239,7 +289,7 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 
 	var user models.User
 	// Get user and associated OAuth providers by email
-	user, result := queries.GetUserAndProvider(request.Email)
+	user, result := queries.GetUserAndProvider(h.DB.WithContext(c), request.Email)
 	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
 		utils.ServerErrorResponse(c, 500, "Error getting user", utils.ErrGetData, result.Error)
 		return
@@ -254,7 +304,7 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 
 	// If the user is found
 	if result.Error == nil {
-		logger.Log.Info("test")
+		h.Logger.Info("test")
 		// Iterate through associated OAuth providers
 		for _, p := range *user.OauthProviders {
 			// Skip to the next iteration if the provider doesn't match
@@ -264,16 +314,22 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 
 			// Check if the OAuthID matches
 			if p.OAuthID != request.UserID {
+				metrics.Logins.WithLabelValues(cprovider, "failure").Inc()
 				utils.FullyResponse(c, 403, "OAuthID mismatched!", utils.ErrUnauthorized, nil)
 				return
 			}
 
 			// Generate user session after successful authentication
-			err = utils.GenerateUserSession(c, user.ID)
+			mfaRequired, err := h.beginLogin(c, user)
 			if err != nil {
 				utils.ServerErrorResponse(c, 500, "Error generating session", utils.ErrGenerateSession, err)
 				return
 			}
+			if mfaRequired {
+				mfaRequiredPage(c, cprovider)
+				return
+			}
+			metrics.Logins.WithLabelValues(cprovider, "success").Inc()
 
 			// Send a successful login response
 			c.HTML(200, "auth_successful.html", gin.H{
@@ -284,7 +340,7 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 		}
 
 		// If provider is new, add it to the database
-		reseult := queries.AddUserProvider(models.OauthProvider{
+		reseult := queries.AddUserProvider(h.DB.WithContext(c), models.OauthProvider{
 			ID:        encryption.GenerateID(),
 			UserID:    user.ID,
 			Provider:  provider,
@@ -300,11 +356,16 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 		}
 
 		// Generate user session after successful provider addition
-		err = utils.GenerateUserSession(c, user.ID)
+		mfaRequired, err := h.beginLogin(c, user)
 		if err != nil {
 			utils.ServerErrorResponse(c, 500, "Error generating session", utils.ErrGenerateSession, err)
 			return
 		}
+		if mfaRequired {
+			mfaRequiredPage(c, cprovider)
+			return
+		}
+		metrics.Logins.WithLabelValues(cprovider, "success").Inc()
 
 		// Send a successful response when a new login option is added
 		c.HTML(200, "auth_successful.html", gin.H{
@@ -331,7 +392,7 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 
 	// Check if the generated username already exists, and regenerate if needed
 	for i := 0; i < 5; i++ {
-		_, result := queries.GetUserQueueByUsername(user.Username)
+		_, result := queries.GetUserQueueByUsername(h.DB.WithContext(c), user.Username)
 		if result.Error == gorm.ErrRecordNotFound {
 			break // No conflict, break the loop
 		}
@@ -348,13 +409,13 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 	}
 
 	// Create the new user in the database
-	result = queries.CreateUserQueue(user)
+	result = queries.CreateUserQueue(h.DB.WithContext(c), user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		utils.ServerErrorResponse(c, 500, "Error creating user", utils.ErrSaveData, result.Error)
 		return
 	}
 
-	reseult := queries.AddUserProvider(models.OauthProvider{
+	reseult := queries.AddUserProvider(h.DB.WithContext(c), models.OauthProvider{
 		ID:        encryption.GenerateID(),
 		UserID:    user.ID,
 		Provider:  provider,
@@ -369,11 +430,12 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 	}
 
 	// Generate user session after successful user creation
-	err = utils.GenerateUserSession(c, user.ID)
+	err = utils.GenerateUserSession(c, h.DB.WithContext(c), user.ID, time.Now())
 	if err != nil {
 		utils.ServerErrorResponse(c, 500, "Error generating session", utils.ErrGenerateSession, err)
 		return
 	}
+	metrics.Signups.WithLabelValues(cprovider).Inc()
 
 	// Send a successful response for new user signup
 	c.HTML(200, "auth_successful.html", gin.H{
@@ -382,7 +444,16 @@ func OAuthCallbackHandler(c *gin.Context, cprovider string) {
 	})
 }
 
-func RefreshAccessToken(c *gin.Context) {
+// mfaRequiredPage tells the user logging in with OAuth to give their two-factor code in the app
+func mfaRequiredPage(c *gin.Context, provider string) {
+	metrics.Logins.WithLabelValues(provider, "mfa_required").Inc()
+	c.HTML(200, "auth_successful.html", gin.H{
+		"Title":   "Two-factor code required",
+		"Message": "Go back to InstructHub and enter the code of your authenticator app to finish logging in.",
+	})
+}
+
+func (h *Handler) RefreshAccessToken(c *gin.Context) {
 	// Retrieve the refresh token from the cookie
 	refreshToken, err := c.Cookie("refresh_token")
 	if err != nil {
@@ -391,7 +462,7 @@ func RefreshAccessToken(c *gin.Context) {
 	}
 
 	// Check refresh token valid
-	session, result := queries.GetSessionQueueBySecretKey(refreshToken)
+	session, result := queries.GetSessionQueueBySecretKey(h.DB.WithContext(c), refreshToken)
 	if result.Error == gorm.ErrRecordNotFound {
 		utils.FullyResponse(c, 403, "Invalid refresh token", utils.ErrUnauthorized, nil)
 		return
@@ -401,7 +472,7 @@ func RefreshAccessToken(c *gin.Context) {
 	}
 
 	// Delete this session
-	result = queries.DeleteSessionQueue(refreshToken)
+	result = queries.DeleteSessionQueue(h.DB.WithContext(c), refreshToken)
 	if result.Error != nil {
 		utils.ServerErrorResponse(c, 500, "Error delete session", utils.ErrDeleteData, result.Error)
 		return
@@ -411,7 +482,7 @@ func RefreshAccessToken(c *gin.Context) {
 	}
 
 	// Set the new access token in the response cookie
-	err = utils.GenerateUserSession(c, session.UserID)
+	err = utils.GenerateUserSession(c, h.DB.WithContext(c), session.UserID, session.AuthenticatedAt)
 	if err != nil {
 		utils.ServerErrorResponse(c, 500, "Error generate session", utils.ErrGenerateSession, err)
 		return
@@ -420,7 +491,7 @@ func RefreshAccessToken(c *gin.Context) {
 	utils.FullyResponse(c, 200, "Successfully refreshed access token", nil, nil)
 }
 
-func LogOut(c *gin.Context) {
+func (h *Handler) LogOut(c *gin.Context) {
 	// Retrieve the refresh token from the cookie
 	refreshToken, err := c.Cookie("refresh_token")
 	if err != nil {
@@ -429,7 +500,7 @@ func LogOut(c *gin.Context) {
 	}
 
 	// Attempt to delete the session associated with the refresh token
-	result := queries.DeleteSessionQueue(refreshToken)
+	result := queries.DeleteSessionQueue(h.DB.WithContext(c), refreshToken)
 	if result.Error != nil {
 		utils.ServerErrorResponse(c, 500, "Error delete session", utils.ErrGetData, result.Error)
 		return
@@ -447,7 +518,7 @@ func LogOut(c *gin.Context) {
 }
 
 // CheckEmailVerify checks if the user's email has been verified
-func CheckEmailVerify(c *gin.Context) {
+func (h *Handler) CheckEmailVerify(c *gin.Context) {
 	type resp struct {
 		Verify bool `json:"verify"`
 	}
@@ -460,7 +531,7 @@ func CheckEmailVerify(c *gin.Context) {
 	}
 
 	// Retrieve user from the database
-	user, result := queries.GetUserQueueByID(userID)
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
 	if result.Error == gorm.ErrRecordNotFound {
 		utils.FullyResponse(c, 404, "User not found", utils.ErrGetData, nil)
 		return
@@ -476,12 +547,12 @@ func CheckEmailVerify(c *gin.Context) {
 }
 
 // VerifyEmail handles the email verification process
-func VerifyEmail(c *gin.Context) {
+func (h *Handler) VerifyEmail(c *gin.Context) {
 	// Get the verifyKey from the URL parameters
 	verifyKey := c.Param("verifyKey")
 
 	// Check if the verifyKey exists in Redis
-	userIDString, err := cache.RedisClient.Get(c, verifyKey).Result()
+	userIDString, err := h.Cache.Get(c, verifyKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			utils.FullyResponse(c, 400, "Invalid verification key", utils.ErrBadRequest, nil)
@@ -501,14 +572,14 @@ func VerifyEmail(c *gin.Context) {
 	}
 
 	// Update the user's email verification status in the database
-	result := queries.UpdateUserVerifyStatus(userID, true)
+	result := queries.UpdateUserVerifyStatus(h.DB.WithContext(c), userID, true)
 	if result.Error != nil || result.RowsAffected == 0 {
 		utils.ServerErrorResponse(c, 500, "Error updating user email verification status", utils.ErrSaveData, result.Error)
 		return
 	}
 
 	// Delete the verifyKey from Redis after successful verification
-	err = cache.RedisClient.Del(c, verifyKey).Err()
+	err = h.Cache.Del(c, verifyKey).Err()
 	if err != nil {
 		utils.ServerErrorResponse(c, 500, "Error delete data frm redis", utils.ErrSaveData, err)
 		return
@@ -516,7 +587,7 @@ func VerifyEmail(c *gin.Context) {
 
 	_, exist := c.Get("userID")
 	if exist {
-		err = utils.GenerateUserSession(c, userID)
+		err = utils.GenerateUserSession(c, h.DB.WithContext(c), userID, time.Now())
 		if err != nil {
 			utils.ServerErrorResponse(c, 500, "Error generate session", utils.ErrGenerateSession, err)
 			return
@@ -528,9 +599,8 @@ func VerifyEmail(c *gin.Context) {
 	c.Redirect(303, utils.FrontendURl+"/login?verify=true")
 }
 
-// TODO: Need to use a rate limiter with 60 secs per request
 // ResendVerificationEmail handles resending the verification email
-func ResendVerificationEmail(c *gin.Context) {
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
 	// Get the userID from the URL parameters
 	ContextUserID, exist := c.Get("userID")
 	if !exist {
@@ -541,7 +611,7 @@ func ResendVerificationEmail(c *gin.Context) {
 	userID := ContextUserID.(uint64)
 
 	// Retrieve user details from the database
-	user, result := queries.GetUserQueueByID(userID)
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
 	if result.Error == gorm.ErrRecordNotFound {
 		utils.FullyResponse(c, 404, "User not find", utils.ErrGetData, nil)
 		return
@@ -557,51 +627,43 @@ func ResendVerificationEmail(c *gin.Context) {
 		return
 	}
 
-	// Generate a random verification token
+	// Send the verification email
+	if err := h.SendVerificationEmail(c, user); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error sending verification email", utils.ErrSendEmail, err)
+		return
+	}
+
+	// Return a success response
+	utils.FullyResponse(c, 200, "Verification email successfully sent", nil, nil)
+}
+
+// SendVerificationEmail sends a verification link to the user, the link is valid for 15 minutes
+func (h *Handler) SendVerificationEmail(ctx context.Context, user models.User) error {
 	verifyToken, err := encryption.GenerateRandomBase64String(512)
 	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error generating verification token", utils.ErrGenerateToken, err)
-		return
+		return fmt.Errorf("error generating verification token: %w", err)
 	}
 
-	// Prepare email data
-	type EmailData struct {
+	data := struct {
 		VerifyURL string
 		UserName  string
-	}
-	data := EmailData{
+	}{
 		VerifyURL: utils.BackendURL + "/auth/email/verify/" + verifyToken,
 		UserName:  user.Username,
 	}
 
-	// Render the email body with the template
 	var emailBody bytes.Buffer
-	t := template.New("Email verification")
-	t, err = t.ParseFiles("template/email_verificaiton.html")
+	t, err := template.New("Email verification").ParseFiles("template/email_verificaiton.html")
 	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error parsing email template", utils.ErrParseFile, err)
-		return
+		return fmt.Errorf("error parsing email template: %w", err)
 	}
-	err = t.ExecuteTemplate(&emailBody, "email_verificaiton.html", data)
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error executing email template", utils.ErrExecuteTemplate, err)
-		return
+	if err := t.ExecuteTemplate(&emailBody, "email_verificaiton.html", data); err != nil {
+		return fmt.Errorf("error executing email template: %w", err)
 	}
-
-	// Send the verification email
-	err = utils.SendEmail(user.Email, "Verify your email", emailBody.String())
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error sending verification email", utils.ErrSendEmail, err)
-		return
+	if err := h.Mailer.Send(user.Email, "Verify your email", emailBody.String()); err != nil {
+		return err
 	}
 
 	// Store the verification token in Redis with an expiration of 15 minutes
-	err = cache.RedisClient.Set(c, verifyToken, user.ID, 15*time.Minute).Err()
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error storing verification token in Redis", utils.ErrStoreRedis, err)
-		return
-	}
-
-	// Return a success response
-	utils.FullyResponse(c, 200, "Verification email successfully sent", nil, nil)
+	return h.Cache.Set(ctx, verifyToken, user.ID, 15*time.Minute).Err()
 }
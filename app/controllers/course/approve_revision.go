@@ -1,36 +1,36 @@
 package courses
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
-	"code.gitea.io/sdk/gitea"
 	"github.com/gin-gonic/gin"
 	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/app/queries"
-	"github.com/instructhub/backend/pkg/encryption"
-	git "github.com/instructhub/backend/pkg/gitea"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/metrics"
 	"github.com/instructhub/backend/pkg/utils"
 	"gorm.io/gorm"
 )
 
-// FIXME: Check user premission have permission to approve
-// ApproveRevision handles approving a course revision
-func ApproveRevision(c *gin.Context) {
-	courseID, _, revisionID, err := parseIDs(c)
+// ApproveRevision handles approving a course revision, only maintainers can approve
+func (h *Handler) ApproveRevision(c *gin.Context) {
+	courseID, userID, revisionID, err := parseIDs(c)
 	if err != nil {
 		utils.FullyResponse(c, 400, "Invalid course or revision ID", utils.ErrBadRequest, err.Error())
 		return
 	}
 
 	// Fetch revision details
-	revision, result := queries.GetCourseRevision(courseID, revisionID)
+	revision, result := queries.GetCourseRevision(h.DB.WithContext(c), courseID, revisionID)
 	if result.Error == gorm.ErrRecordNotFound {
 		utils.FullyResponse(c, 404, "Revision not found", utils.ErrCourseNotExist, nil)
 		return
 	} else if result.Error != nil {
-		utils.ServerErrorResponse(c, 500, "Error fetching course data", utils.ErrGetData, err)
+		utils.ServerErrorResponse(c, 500, "Error fetching course data", utils.ErrGetData, result.Error)
 		return
 	}
 
@@ -39,59 +39,127 @@ func ApproveRevision(c *gin.Context) {
 		utils.FullyResponse(c, 400, "Revision already merged", utils.ErrAlreadyMerged, nil)
 		return
 	}
-
-	// Fetch course data from git
-	revisionData, err := fetchCourseDataFromGit(revision)
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error fetching course data", utils.ErrGetData, err)
+	if !ensureRevisionReady(c, revision) {
 		return
 	}
-
-	// Parse course data into the update request
-	var updateRequest UpdateRequestCourse
-	err = json.Unmarshal([]byte(revisionData), &updateRequest)
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error unmarshaling data", utils.ErrUnmarshal, err)
+	if !revision.Status.CanTransitionTo(models.RevisionMerging) {
+		utils.FullyResponse(c, 400, "Only open revisions can be merged", utils.ErrInvalidRevisionStatus, nil)
 		return
 	}
 
-	// Prepare course modules and steps for update
-	needUpdateModules, needUpdateSteps, needCreateModules, needCreateSteps := prepareCourseData(courseID, revision, updateRequest)
-
-	// Update modules and steps in the database
-	err = updateCourseModules(needUpdateModules)
-	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error updating module data", utils.ErrSaveData, err)
+	// Claim the revision before rebasing it, a concurrent approval stops here
+	result = queries.StartRevisionMerge(h.DB.WithContext(c), revision.ID, userID)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating revision", utils.ErrSaveData, result.Error)
+		return
+	} else if result.RowsAffected == 0 {
+		utils.FullyResponse(c, 409, "Revision is already being merged", utils.ErrInvalidRevisionStatus, nil)
 		return
 	}
+	revision.Status = models.RevisionMerging
+	revision.ApproverID = &userID
 
-	err = updateCourseSteps(needUpdateSteps)
+	// Bring the revision on top of the current course so it doesn't revert what was merged since it was opened
+	revision, report, err := h.rebaseRevision(c, revision, userID)
 	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error updating step data", utils.ErrSaveData, err)
+		h.releaseRevisionMerge(c, revision)
+		utils.ServerErrorResponse(c, 500, "Error rebasing revision", utils.ErrSaveCourseFile, err)
+		return
+	}
+	if report != nil {
+		h.releaseRevisionMerge(c, revision)
+		utils.FullyResponse(c, 409, "Revision conflicts with the current course", utils.ErrRevisionConflict, report)
 		return
 	}
 
-	// Create new modules and steps
-	err = createCourseModules(needCreateModules)
+	updateRequest, err := h.completeRevisionApproval(c, revision)
 	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error creating new module data", utils.ErrSaveData, err)
+		utils.ServerErrorResponse(c, 500, "Error merging revision and pull request", utils.ErrSaveData, err)
 		return
 	}
 
-	err = createCourseSteps(needCreateSteps)
+	utils.FullyResponse(c, 200, "Revision successfully approved", nil, updateRequest)
+}
+
+// ResumeRevisionApproval re-drives the approval of a revision left in merging
+func (h *Handler) ResumeRevisionApproval(ctx context.Context, revision models.CourseRevision) error {
+	revision, result := queries.GetCourseRevision(h.DB.WithContext(ctx), revision.CourseID, revision.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if revision.Status != models.RevisionMerging {
+		return nil
+	}
+
+	_, err := h.completeRevisionApproval(ctx, revision)
+	return err
+}
+
+// completeRevisionApproval applies the revision to the course and merges its pull request as one unit,
+// the revision goes back to open when neither happened and stays merging when the outcome is unknown
+func (h *Handler) completeRevisionApproval(ctx context.Context, revision models.CourseRevision) (UpdateRequestCourse, error) {
+	// Fetch course data from git
+	revisionData, err := h.fetchCourseDataFromGit(ctx, revision)
 	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error creating new step data", utils.ErrSaveData, err)
-		return
+		return UpdateRequestCourse{}, h.compensateRevisionApproval(ctx, revision, err)
 	}
 
-	// Update revision status and merge the pull request
-	err = mergeRevisionAndPullRequest(revision, courseID)
+	// Parse course data into the update request
+	var updateRequest UpdateRequestCourse
+	if err := json.Unmarshal([]byte(revisionData), &updateRequest); err != nil {
+		return updateRequest, h.compensateRevisionApproval(ctx, revision, err)
+	}
+
+	// Prepare course modules and steps for update
+	needUpdateModules, needUpdateSteps, needCreateModules, needCreateSteps := prepareCourseData(revision.CourseID, revision, updateRequest)
+
+	// The pull request is merged last so the transaction is only committed once git has the changes
+	err = h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := queries.UpdateCourseRevisionStatus(tx, revision.ID, models.RevisionMerging, models.RevisionMerged)
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return fmt.Errorf("revision %d is not merging anymore", revision.ID)
+		}
+
+		if err := updateCourseModules(tx, needUpdateModules); err != nil {
+			return err
+		}
+		if err := updateCourseSteps(tx, needUpdateSteps); err != nil {
+			return err
+		}
+		if err := createCourseModules(tx, needCreateModules); err != nil {
+			return err
+		}
+		if err := createCourseSteps(tx, needCreateSteps); err != nil {
+			return err
+		}
+
+		return h.mergePullRequest(ctx, revision)
+	})
 	if err != nil {
-		utils.ServerErrorResponse(c, 500, "Error merging revision and pull request", utils.ErrSaveData, nil)
-		return
+		return updateRequest, h.compensateRevisionApproval(ctx, revision, err)
 	}
+	metrics.RevisionsApproved.Inc()
 
-	utils.FullyResponse(c, 200, "Revision successfully approved", nil, updateRequest)
+	return updateRequest, nil
+}
+
+// compensateRevisionApproval puts a failed approval back to open when its pull request was not merged,
+// otherwise the revision is left merging for the recovery job
+func (h *Handler) compensateRevisionApproval(ctx context.Context, revision models.CourseRevision, cause error) error {
+	changeRequest, err := h.Content.GetChangeRequest(ctx, utils.Uint64ToStr(revision.CourseID), int64(revision.PullRequestID))
+	if err != nil || changeRequest.Merged {
+		return cause
+	}
+
+	h.releaseRevisionMerge(ctx, revision)
+	return cause
+}
+
+// releaseRevisionMerge puts a claimed revision back to open when its approval stopped before merging
+func (h *Handler) releaseRevisionMerge(ctx context.Context, revision models.CourseRevision) {
+	queries.UpdateCourseRevisionStatus(h.DB.WithContext(ctx), revision.ID, models.RevisionMerging, models.RevisionOpen)
 }
 
 // parseIDs extracts courseID, userID, and revisionID from context and validates them
@@ -116,18 +184,38 @@ func parseIDs(c *gin.Context) (uint64, uint64, uint64, error) {
 }
 
 // fetchCourseDataFromGit retrieves the course data from Git
-func fetchCourseDataFromGit(revision models.CourseRevision) (string, error) {
-	revisionChangeFile, _, err := git.GiteaClient.GetContents(utils.GiteaORGName, utils.Uint64ToStr(revision.CourseID), utils.Uint64ToStr(revision.BranchID), "/course_data.json")
-	if err != nil {
-		return "", err
+func (h *Handler) fetchCourseDataFromGit(ctx context.Context, revision models.CourseRevision) (string, error) {
+	revisionChangeDataString, _, err := h.fetchGitFile(ctx, revision.CourseID, utils.Uint64ToStr(revision.BranchID), models.CourseDataFile)
+	return revisionChangeDataString, err
+}
+
+// fetchGitFile retrieves a file from Git at the given ref, exists is false when the file is not there
+func (h *Handler) fetchGitFile(ctx context.Context, courseID uint64, ref string, path string) (data string, exists bool, err error) {
+	file, err := h.Content.ReadFile(ctx, utils.Uint64ToStr(courseID), ref, path)
+	if errors.Is(err, content.ErrNotFound) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
 	}
+	return string(file), true, nil
+}
 
-	revisionChangeDataString, err := encryption.Base64Decode(*revisionChangeFile.Content)
+// fetchCourseData retrieves and parses the course data from Git at the given ref
+func (h *Handler) fetchCourseData(ctx context.Context, courseID uint64, ref string) (UpdateRequestCourse, error) {
+	var courseData UpdateRequestCourse
+
+	courseDataString, _, err := h.fetchGitFile(ctx, courseID, ref, models.CourseDataFile)
 	if err != nil {
-		return "", err
+		return courseData, err
+	}
+
+	// A new course starts with an empty course data file
+	if courseDataString == "" {
+		return courseData, nil
 	}
 
-	return revisionChangeDataString, nil
+	err = json.Unmarshal([]byte(courseDataString), &courseData)
+	return courseData, err
 }
 
 // prepareCourseData prepares modules and steps for update or creation
@@ -221,9 +309,9 @@ func prepareCourseData(courseID uint64, revision models.CourseRevision, updateRe
 }
 
 // updateCourseModules updates existing course modules in the database
-func updateCourseModules(needUpdateModules []models.CourseModule) error {
+func updateCourseModules(tx *gorm.DB, needUpdateModules []models.CourseModule) error {
 	for _, module := range needUpdateModules {
-		result := queries.UpdateCourseModule(module)
+		result := queries.UpdateCourseModule(tx, module)
 		if result.Error != nil || result.RowsAffected == 0 {
 			return fmt.Errorf("failed to update module data")
 		}
@@ -232,9 +320,9 @@ func updateCourseModules(needUpdateModules []models.CourseModule) error {
 }
 
 // updateCourseSteps updates existing course steps in the database
-func updateCourseSteps(needUpdateSteps []models.CourseStep) error {
+func updateCourseSteps(tx *gorm.DB, needUpdateSteps []models.CourseStep) error {
 	for _, step := range needUpdateSteps {
-		result := queries.UpdateCourseStep(step)
+		result := queries.UpdateCourseStep(tx, step)
 		if result.Error != nil || result.RowsAffected == 0 {
 			return fmt.Errorf("failed to update step data")
 		}
@@ -243,11 +331,11 @@ func updateCourseSteps(needUpdateSteps []models.CourseStep) error {
 }
 
 // createCourseModules creates new course modules in the database
-func createCourseModules(needCreateModules []models.CourseModule) error {
+func createCourseModules(tx *gorm.DB, needCreateModules []models.CourseModule) error {
 	if len(needCreateModules) == 0 {
 		return nil
 	}
-	result := queries.CreateCourseModules(needCreateModules)
+	result := queries.CreateCourseModules(tx, needCreateModules)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return fmt.Errorf("failed to create module data")
 	}
@@ -255,32 +343,31 @@ func createCourseModules(needCreateModules []models.CourseModule) error {
 }
 
 // createCourseSteps creates new course steps in the database
-func createCourseSteps(needCreateSteps []models.CourseStep) error {
+func createCourseSteps(tx *gorm.DB, needCreateSteps []models.CourseStep) error {
 	if len(needCreateSteps) == 0 {
 		return nil
 	}
-	result := queries.CreateCourseSteps(needCreateSteps)
+	result := queries.CreateCourseSteps(tx, needCreateSteps)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return fmt.Errorf("failed to create step data")
 	}
 	return nil
 }
 
-// mergeRevisionAndPullRequest merges the revision and the pull request
-func mergeRevisionAndPullRequest(revision models.CourseRevision, courseID uint64) error {
-	revision.UpdatedAt = time.Now()
-	revision.Status = models.RevisionMerged
-	result := queries.UpdateCourseRevision(revision)
-	if result.Error != nil || result.RowsAffected == 0 {
-		return fmt.Errorf("failed to update revision data")
-	}
+// mergePullRequest merges the pull request of the revision unless a previous attempt already did
+func (h *Handler) mergePullRequest(ctx context.Context, revision models.CourseRevision) error {
+	courseName := utils.Uint64ToStr(revision.CourseID)
 
-	mergeOptions := gitea.MergePullRequestOption{
-		Style: "merge",
-	}
-	_, _, err := git.GiteaClient.MergePullRequest(utils.GiteaORGName, utils.Uint64ToStr(courseID), int64(revision.PullRequestID), mergeOptions)
+	changeRequest, err := h.Content.GetChangeRequest(ctx, courseName, int64(revision.PullRequestID))
 	if err != nil {
-		return fmt.Errorf("failed to merge pull request")
+		return fmt.Errorf("failed to check pull request: %w", err)
+	}
+	if changeRequest.Merged {
+		return nil
+	}
+
+	if err := h.Content.MergeChangeRequest(ctx, courseName, int64(revision.PullRequestID)); err != nil {
+		return fmt.Errorf("failed to merge pull request: %w", err)
 	}
 	return nil
 }
@@ -1,26 +1,32 @@
 package courses
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	"code.gitea.io/sdk/gitea"
 	"github.com/gin-gonic/gin"
 	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/app/workers"
+	"github.com/instructhub/backend/pkg/content"
 	"github.com/instructhub/backend/pkg/encryption"
-	git "github.com/instructhub/backend/pkg/gitea"
 	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
 )
 
+// defaultBranch is the branch holding the published content of every course
+const defaultBranch = models.CourseDefaultBranch
+
 // createCourseRequest is the type for the request body of creating a new course.
 type createCourseRequest struct {
 	Name        string `json:"name" binding:"required,max=50"`
 	Description string `json:"description" binding:"required,max=200"`
+	Private     bool   `json:"private"`
 }
 
 // CreateNewCourse creates a new course with the given request data.
-func CreateNewCourse(c *gin.Context) {
+func (h *Handler) CreateNewCourse(c *gin.Context) {
 	// Retrieve user ID from context
 	userID, err := utils.GetUserIDFromContext(c)
 	if err != nil {
@@ -38,27 +44,26 @@ func CreateNewCourse(c *gin.Context) {
 	// Create course object
 	course := createCourse(userID, request)
 
-	// Create a new repository for the course
-	repo, err := createCourseRepo(course)
+	// Save the course and its owner, the repository is created by the outbox worker
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := saveCourseToDatabase(tx, course); err != nil {
+			return err
+		}
+		// The creator becomes the first owner of the course
+		if err := saveCourseOwner(tx, course); err != nil {
+			return err
+		}
+		return enqueueOutboxEvent(tx, models.OutboxCreateCourseRepo, utils.Uint64ToStr(course.ID), models.CourseRepoPayload{
+			CourseID: course.ID,
+			UserID:   userID,
+		})
+	})
 	if err != nil {
-		c.Error(err)
-		utils.FullyResponse(c, 500, "Error creating new course repo", utils.ErrCreateNewCourse, nil)
-		return
-	}
-
-	// Create course data file in the repository
-	if err := createCourseFile(repo, userID); err != nil {
-		c.Error(err)
-		utils.FullyResponse(c, 500, "Error saving course file", utils.ErrCreateNewCourse, nil)
-		return
-	}
-
-	// Save the course information to the database
-	if err := saveCourseToDatabase(course); err != nil {
 		c.Error(err)
 		utils.FullyResponse(c, 500, "Error saving course", utils.ErrSaveData, nil)
 		return
 	}
+	workers.NotifyOutbox()
 
 	// Return success response
 	utils.FullyResponse(c, 201, "Successfully created new course", nil, course)
@@ -71,48 +76,56 @@ func createCourse(userID uint64, request createCourseRequest) models.Course {
 		CreatorID:   userID,
 		Name:        request.Name,
 		Description: request.Description,
+		Private:     request.Private,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 }
 
 // createCourseRepo creates a new Git repository for the course.
-func createCourseRepo(course models.Course) (*gitea.Repository, error) {
-	repoOptions := gitea.CreateRepoOption{
-		Name:          utils.Uint64ToStr(uint64(course.ID)),
-		DefaultBranch: "en",
-		AutoInit:      true,
-		Private:       true,
-	}
-	repo, _, err := git.GiteaClient.CreateOrgRepo(utils.GiteaORGName, repoOptions)
-	return repo, err
+func (h *Handler) createCourseRepo(ctx context.Context, courseID uint64) error {
+	return h.Content.CreateRepo(ctx, utils.Uint64ToStr(courseID), defaultBranch)
 }
 
 // createCourseFile creates the course data file in the new repository.
-func createCourseFile(repo *gitea.Repository, userID uint64) error {
-	giteaFile := gitea.CreateFileOptions{
-		FileOptions: gitea.FileOptions{
-			Message: "init: Initialize the course",
-			Committer: gitea.Identity{
-				Name:  utils.Uint64ToStr(userID),
-				Email: git.GenerateCommmitEmail(userID),
-			},
-			Author: gitea.Identity{
-				Name: utils.Uint64ToStr(userID),
-			},
+func (h *Handler) createCourseFile(ctx context.Context, courseID uint64, userID uint64) error {
+	_, err := h.Content.CommitFiles(ctx, utils.Uint64ToStr(courseID), content.CommitRequest{
+		Branch:  defaultBranch,
+		Message: "init: Initialize the course",
+		Author: content.Identity{
+			Name:  utils.Uint64ToStr(userID),
+			Email: content.CommitEmail(userID),
 		},
-		Content: encryption.Base64Encode(""),
-	}
-
-	_, _, err := git.GiteaClient.CreateFile(repo.Owner.UserName, repo.Name, "course_data.json", giteaFile)
+		Files: []content.File{{
+			Path:      models.CourseDataFile,
+			Content:   []byte{},
+			Operation: content.OperationCreate,
+		}},
+	})
 	return err
 }
 
 // saveCourseToDatabase saves the new course to the database.
-func saveCourseToDatabase(course models.Course) error {
-	result := queries.CreateNewCourse(course)
+func saveCourseToDatabase(tx *gorm.DB, course models.Course) error {
+	result := queries.CreateNewCourse(tx, course)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return fmt.Errorf("failed to save course to database")
 	}
 	return nil
 }
+
+// saveCourseOwner saves the course creator as the owner of the course.
+func saveCourseOwner(tx *gorm.DB, course models.Course) error {
+	result := queries.CreateCourseMember(tx, models.CourseMember{
+		ID:        encryption.GenerateID(),
+		CourseID:  course.ID,
+		UserID:    course.CreatorID,
+		Role:      models.CourseOwner,
+		UpdatedAt: time.Now(),
+		CreatedAt: time.Now(),
+	})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return fmt.Errorf("failed to save course owner to database")
+	}
+	return nil
+}
@@ -1,46 +1,34 @@
 package courses
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
 	"time"
 
-	"code.gitea.io/sdk/gitea"
 	"github.com/gin-gonic/gin"
 	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/app/workers"
+	"github.com/instructhub/backend/pkg/content"
 	"github.com/instructhub/backend/pkg/encryption"
-	git "github.com/instructhub/backend/pkg/gitea"
+	"github.com/instructhub/backend/pkg/metrics"
 	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
 )
 
-type CourseStepRequest struct {
-	ID       *string           `json:"id" binding:"omitempty,number"`
-	ModuleID *string           `json:"module_id,omitempty" binding:"omitempty,number"`
-	Position int               `json:"position" binding:"required"`
-	Type     models.CourseType `json:"type" binding:"number"`
-	Name     string            `json:"name" binding:"max=50"`
-	Updated  *bool             `json:"updated,omitempty" binding:"omitempty"`
-	Content  *string           `json:"content,omitempty" binding:"omitempty,base64,max=100000"`
-}
-
-type CourseModuleRequest struct {
-	ID       *string `json:"id" binding:"omitempty,number"`
-	Position int     `json:"position" binding:"required,number"`
-	Name     string  `json:"name" binding:"required,max=30"`
-
-	CourseSteps []CourseStepRequest `json:"course_steps" binding:"max=20,dive"`
-}
-
-type UpdateRequestCourse struct {
-	Modules     []CourseModuleRequest `json:"modules" binding:"min=1,max=10,dive"`
-	Description string                `json:"description" binding:"required,max=100"`
-}
+// The request of a revision is the new course_data.json
+type (
+	CourseStepRequest   = models.CourseDataStep
+	CourseModuleRequest = models.CourseDataModule
+	UpdateRequestCourse = models.CourseData
+)
 
 // CreateNewRevision handles course content updates
-func CreateNewRevision(c *gin.Context) {
+func (h *Handler) CreateNewRevision(c *gin.Context) {
 	var request UpdateRequestCourse
 
 	// Validate request body
@@ -60,7 +48,7 @@ func CreateNewRevision(c *gin.Context) {
 	sortModulesAndSteps(&request)
 
 	// Fetch old course data
-	oldCourseData, result := queries.GetCourseWithDetails(courseID)
+	oldCourseData, result := queries.GetCourseWithDetails(h.DB.WithContext(c), courseID)
 	if result.RowsAffected == 0 {
 		utils.FullyResponse(c, 404, "Course not exist", utils.ErrCourseNotExist, nil)
 		return
@@ -77,9 +65,9 @@ func CreateNewRevision(c *gin.Context) {
 	}
 
 	// Process updates for course steps
-	request, updateFiles, err := processCourseSteps(request, oldCourseData)
+	request, updateFiles, err := processCourseSteps(request, courseStepIDs(oldCourseData))
 	if err != nil {
-		utils.FullyResponse(c, 500, "Error processing course steps", utils.ErrSaveCourseFile, nil)
+		utils.FullyResponse(c, 400, "Error processing course steps", utils.ErrBadRequest, err.Error())
 		return
 	}
 
@@ -90,19 +78,38 @@ func CreateNewRevision(c *gin.Context) {
 		return
 	}
 
-	// Add or update course data in git
-	courseRevision, err := updateCourseInGit(courseID, updateFiles, request.Description, courseDataJson, userID)
-	if err != nil {
-		utils.FullyResponse(c, 500, "Error updating course in git", utils.ErrSaveCourseFile, nil)
-		return
+	courseRevision := models.CourseRevision{
+		ID:          encryption.GenerateID(),
+		CourseID:    courseID,
+		BranchID:    encryption.GenerateID(),
+		Description: request.Description,
+		EditorID:    userID,
+		Status:      models.RevisionOpen,
+		UpdatedAt:   time.Now(),
+		CreatedAt:   time.Now(),
 	}
 
-	// Create a course revision revision
-	err = createCourseRevision(*courseRevision)
+	// Save the revision, its branch and pull request are created by the outbox worker
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := createCourseRevision(tx, courseRevision); err != nil {
+			return err
+		}
+		return enqueueOutboxEvent(tx, models.OutboxCreateRevision, utils.Uint64ToStr(courseRevision.ID), models.RevisionCreatePayload{
+			RevisionID: courseRevision.ID,
+			CourseID:   courseID,
+			BranchID:   courseRevision.BranchID,
+			UserID:     userID,
+			Message:    request.Description,
+			Files:      toOutboxFiles(updateFiles),
+			CourseData: courseDataJson,
+		})
+	})
 	if err != nil {
-		utils.FullyResponse(c, 500, "Error creating course revision", utils.ErrSaveData, nil)
+		utils.ServerErrorResponse(c, 500, "Error creating course revision", utils.ErrSaveData, err)
 		return
 	}
+	workers.NotifyOutbox()
+	metrics.RevisionsCreated.Inc()
 
 	utils.FullyResponse(c, 201, "Successfully created a new revision request", nil, courseRevision)
 }
@@ -149,34 +156,54 @@ func validatePositions(modules []CourseModuleRequest) error {
 	return nil
 }
 
-// processCourseSteps processes course steps, identifies new and deleted steps, and prepares update files
-func processCourseSteps(request UpdateRequestCourse, oldCourseData models.Course) (UpdateRequestCourse, []git.File, error) {
-	updateFiles := []git.File{}
+// courseStepIDs collects the IDs of the steps saved in the database
+func courseStepIDs(course models.Course) map[string]bool {
+	stepIDs := make(map[string]bool)
+	if course.CourseModules == nil {
+		return stepIDs
+	}
+	for _, module := range *course.CourseModules {
+		if module.CourseSteps == nil {
+			continue
+		}
+		for _, step := range *module.CourseSteps {
+			stepIDs[utils.Uint64ToStr(step.ID)] = true
+		}
+	}
+	return stepIDs
+}
+
+// processCourseSteps processes course steps against the existing steps, identifies new and deleted steps, and prepares update files
+func processCourseSteps(request UpdateRequestCourse, existingStepIDs map[string]bool) (UpdateRequestCourse, []content.File, error) {
+	updateFiles := []content.File{}
 	newCourseSteps := make(map[string]bool)
 
-	// Identify new and updated steps
+	// Identify kept and updated steps, their ID must already exist
 	for _, module := range request.Modules {
 		for _, step := range module.CourseSteps {
 			if step.ID == nil {
 				continue
 			}
+			if !existingStepIDs[*step.ID] {
+				return request, nil, fmt.Errorf("step %s does not exist", *step.ID)
+			}
+			if step.Updated != nil && *step.Updated && step.Content == nil {
+				return request, nil, fmt.Errorf("step %s is updated without content", *step.ID)
+			}
 			newCourseSteps[*step.ID] = true
 		}
 	}
 
 	// Identify deleted steps
-	for _, module := range *oldCourseData.CourseModules {
-		for _, step := range *module.CourseSteps {
-			if _, exists := newCourseSteps[utils.Uint64ToStr(step.ID)]; !exists {
-				updateFiles = append(updateFiles, git.File{
-					Path:      utils.Uint64ToStr(step.ID),
-					Operation: git.OperationDelete,
-				})
-			}
+	for stepID := range existingStepIDs {
+		if !newCourseSteps[stepID] {
+			updateFiles = append(updateFiles, content.File{
+				Path:      stepID,
+				Operation: content.OperationDelete,
+			})
 		}
 	}
 
-	// FIXME: Need to check if the id really new
 	// Process new and updated course steps
 	for i := range request.Modules {
 		module := &request.Modules[i]
@@ -188,18 +215,29 @@ func processCourseSteps(request UpdateRequestCourse, oldCourseData models.Course
 		for j := range module.CourseSteps {
 			step := &module.CourseSteps[j]
 			if step.ID == nil {
+				if step.Content == nil {
+					return request, nil, fmt.Errorf("new step %q has no content", step.Name)
+				}
+				stepContent, err := base64.StdEncoding.DecodeString(*step.Content)
+				if err != nil {
+					return request, nil, fmt.Errorf("content of new step %q is not base64", step.Name)
+				}
 				stepID := encryption.GenerateID()
 				step.ID = utils.Uint64ToStrPtr(stepID)
-				updateFiles = append(updateFiles, git.File{
+				updateFiles = append(updateFiles, content.File{
 					Path:      *step.ID,
-					Content:   *step.Content,
-					Operation: git.OperationCreate,
+					Content:   stepContent,
+					Operation: content.OperationCreate,
 				})
 			} else if step.Updated != nil && *step.Updated {
-				updateFiles = append(updateFiles, git.File{
+				stepContent, err := base64.StdEncoding.DecodeString(*step.Content)
+				if err != nil {
+					return request, nil, fmt.Errorf("content of step %s is not base64", *step.ID)
+				}
+				updateFiles = append(updateFiles, content.File{
 					Path:      *step.ID,
-					Content:   *step.Content,
-					Operation: git.OperationUpdate,
+					Content:   stepContent,
+					Operation: content.OperationUpdate,
 				})
 			}
 			step.Content = nil
@@ -209,71 +247,47 @@ func processCourseSteps(request UpdateRequestCourse, oldCourseData models.Course
 	return request, updateFiles, nil
 }
 
-// encodeCourseData marshals course data into JSON and encodes it as base64
-func encodeCourseData(request UpdateRequestCourse) (string, error) {
-	courseDataJson, err := json.Marshal(request)
-	if err != nil {
-		return "", err
-	}
-	return encryption.Base64Encode(string(courseDataJson)), nil
+// encodeCourseData marshals course data into JSON
+func encodeCourseData(request UpdateRequestCourse) ([]byte, error) {
+	return json.Marshal(request)
 }
 
-// updateCourseInGit updates the course data and files in the git repository
-func updateCourseInGit(courseID uint64, updateFiles []git.File, description, courseDataJson string, userID uint64) (courseRevision *models.CourseRevision, err error) {
-	updateFiles = append(updateFiles, git.File{
+// commitCourseChanges commits the files and the course data as the user, the target branch is set in commitRequest
+func (h *Handler) commitCourseChanges(ctx context.Context, courseID uint64, updateFiles []content.File, message string, courseDataJson []byte, userID uint64, commitRequest content.CommitRequest) (content.Commit, error) {
+	updateFiles = append(updateFiles, content.File{
 		Content:   courseDataJson,
-		Path:      "course_data.json",
-		Operation: git.OperationUpdate,
+		Path:      models.CourseDataFile,
+		Operation: content.OperationUpdate,
 	})
 
-	identity := gitea.Identity{
+	commitRequest.Author = content.Identity{
 		Name:  utils.Uint64ToStr(userID),
-		Email: git.GenerateCommmitEmail(userID),
+		Email: content.CommitEmail(userID),
 	}
+	commitRequest.Files = updateFiles
+	commitRequest.Message = message
 
-	branchID := encryption.GenerateID()
-
-	modifyRequest := git.ModifyRequest{
-		Author:    identity,
-		Committer: identity,
-		Files:     updateFiles,
-		Message:   description,
-		NewBranch: utils.Uint64ToStr(branchID),
-	}
-
-	// Perform the modification on git
-	if err := git.ModifyMultipleFiles(utils.GiteaORGName, utils.Uint64ToStr(courseID), modifyRequest); err != nil {
-		return nil, err
-	}
-
-	// Create pull request for the changes
-	prOptions := gitea.CreatePullRequestOption{
-		Head:  utils.Uint64ToStr(branchID),
-		Base:  "en",
-		Title: description,
-	}
-
-	pullRequest, _, err := git.GiteaClient.CreatePullRequest(utils.GiteaORGName, utils.Uint64ToStr(courseID), prOptions)
+	return h.Content.CommitFiles(ctx, utils.Uint64ToStr(courseID), commitRequest)
+}
 
-	courseRevision = &models.CourseRevision{
-		ID:            encryption.GenerateID(),
-		CourseID:      courseID,
-		BranchID:      branchID,
-		Description:   description,
-		PullRequestID: int(pullRequest.Index),
-		EditorID:      userID,
-		Status:        models.RevisionOpen,
-		UpdatedAt:     time.Now(),
-		CreatedAt:     time.Now(),
+// newRevisionCommit builds the record of a commit pushed to a revision
+func newRevisionCommit(revisionID, userID uint64, commit content.Commit) models.RevisionCommit {
+	revisionCommit := models.RevisionCommit{
+		ID:         encryption.GenerateID(),
+		RevisionID: revisionID,
+		SHA:        commit.SHA,
+		AuthorID:   userID,
+		Message:    commit.Message,
+		ParentSHA:  commit.Parent(),
+		CreatedAt:  time.Now(),
 	}
-
-	return courseRevision, err
+	return revisionCommit
 }
 
 // createCourseRevision creates a revision entry for the course revision
-func createCourseRevision(courseRevision models.CourseRevision) error {
+func createCourseRevision(tx *gorm.DB, courseRevision models.CourseRevision) error {
 	// Save the course revision revision
-	result := queries.CreateNewCourseRevision(courseRevision)
+	result := queries.CreateNewCourseRevision(tx, courseRevision)
 	if result.RowsAffected == 0 {
 		return fmt.Errorf("failed create new course revision")
 	}
@@ -1,13 +1,14 @@
 package courses
 
 import (
+	"encoding/base64"
+
 	"github.com/gin-gonic/gin"
-	git "github.com/instructhub/backend/pkg/gitea"
 	"github.com/instructhub/backend/pkg/utils"
 )
 
 // GetStepContent handles get ccourse steps content data
-func GetStepContent(c *gin.Context) {
+func (h *Handler) GetStepContent(c *gin.Context) {
 	// Parse course ID and step ID
 	courseID, err := utils.StrToUint64(c.Param("courseID"))
 	if err != nil {
@@ -21,11 +22,11 @@ func GetStepContent(c *gin.Context) {
 		return
 	}
 
-	stepContent, _, err := git.GiteaClient.GetContents(utils.GiteaORGName, utils.Uint64ToStr(courseID), "", utils.Uint64ToStr(stepID))
+	stepContent, err := h.Content.ReadFile(c, utils.Uint64ToStr(courseID), defaultBranch, utils.Uint64ToStr(stepID))
 	if err != nil {
 		utils.FullyResponse(c, 404, "Course or step not exist", utils.ErrCourseNotExist, nil)
 		return
 	}
 
-	utils.FullyResponse(c, 200, "Successfully get course step content", nil, stepContent.Content)
+	utils.FullyResponse(c, 200, "Successfully get course step content", nil, base64.StdEncoding.EncodeToString(stepContent))
 }
@@ -7,7 +7,7 @@ import (
 )
 
 // GetCourse handles get ccourse data (only module and step no step content)
-func GetCourse(c *gin.Context) {
+func (h *Handler) GetCourse(c *gin.Context) {
 	// Parse course ID
 	courseID, err := utils.StrToUint64(c.Param("courseID"))
 	if err != nil {
@@ -16,7 +16,7 @@ func GetCourse(c *gin.Context) {
 	}
 
 	// Fetch course data
-	courseData, result := queries.GetCourseWithDetails(courseID)
+	courseData, result := queries.GetCourseWithDetails(h.DB.WithContext(c), courseID)
 	if result.RowsAffected == 0 {
 		utils.FullyResponse(c, 404, "Course not exist", utils.ErrCourseNotExist, nil)
 		return
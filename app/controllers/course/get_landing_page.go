@@ -10,7 +10,7 @@ import (
 )
 
 // GetCourseLandingPageData handles fetching the landing page details of a course
-func GetCourseLandingPageData(c *gin.Context) {
+func (h *Handler) GetCourseLandingPageData(c *gin.Context) {
 	courseID, err := utils.StrToUint64(c.Param("courseID"))
 	if err != nil {
 		utils.FullyResponse(c, http.StatusBadRequest, "Invalid course ID", utils.ErrBadRequest, nil)
@@ -19,7 +19,7 @@ func GetCourseLandingPageData(c *gin.Context) {
 
 	// Retrieve the landing page data for the course
 	landingPage := models.CourseLandingPage{CourseID: courseID}
-	landingPage, result := queries.GetCourseLandingPage(landingPage.CourseID)
+	landingPage, result := queries.GetCourseLandingPage(h.DB.WithContext(c), landingPage.CourseID)
 	if result.Error != nil {
 		if result.RowsAffected == 0 {
 			utils.FullyResponse(c, http.StatusNotFound, "Landing page not found for the course", utils.ErrCourseNotExist, nil)
@@ -0,0 +1,162 @@
+package courses
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
+)
+
+// revisionListResponse is a page of course revisions
+type revisionListResponse struct {
+	Revisions []models.CourseRevision `json:"revisions"`
+	Total     int64                   `json:"total"`
+	Page      int                     `json:"page"`
+	PageSize  int                     `json:"page_size"`
+}
+
+// revisionDetailResponse is a course revision with the public data of its editor and approver
+type revisionDetailResponse struct {
+	models.CourseRevision
+	Editor   *models.UserPublicProfile `json:"editor,omitempty"`
+	Approver *models.UserPublicProfile `json:"approver,omitempty"`
+	Commits  []revisionCommitResponse  `json:"commits"`
+}
+
+// revisionCommitResponse is a revision commit with the public data of its author
+type revisionCommitResponse struct {
+	models.RevisionCommit
+	Author *models.UserPublicProfile `json:"author,omitempty"`
+}
+
+// ListRevisions handles listing the revisions of a course, filtered by status
+func (h *Handler) ListRevisions(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+
+	var status *models.RevisionStatus
+	if statusQuery := c.Query("status"); statusQuery != "" {
+		parsedStatus, ok := models.ParseStringToRevisionStatus(statusQuery)
+		if !ok {
+			utils.FullyResponse(c, 400, "Invalid revision status", utils.ErrBadRequest, nil)
+			return
+		}
+		status = &parsedStatus
+	}
+
+	page, pageSize := utils.GetPagination(c)
+
+	revisions, total, result := queries.GetCourseRevisions(h.DB.WithContext(c), course.ID, status, (page-1)*pageSize, pageSize)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revisions", utils.ErrGetData, result.Error)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Successfully get revisions", nil, revisionListResponse{
+		Revisions: revisions,
+		Total:     total,
+		Page:      page,
+		PageSize:  pageSize,
+	})
+}
+
+// GetRevision handles getting the details of a course revision
+func (h *Handler) GetRevision(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+
+	revisionID, err := utils.StrToUint64(c.Param("revisionID"))
+	if err != nil {
+		utils.FullyResponse(c, 400, "Invalid revision ID", utils.ErrBadRequest, nil)
+		return
+	}
+
+	revision, result := queries.GetCourseRevisionWithUsers(h.DB.WithContext(c), course.ID, revisionID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 404, "Revision not found", utils.ErrRivisionNotExist, nil)
+		return
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revision", utils.ErrGetData, result.Error)
+		return
+	}
+
+	commits, result := queries.GetRevisionCommits(h.DB.WithContext(c), revision.ID)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revision commits", utils.ErrGetData, result.Error)
+		return
+	}
+
+	response, err := newRevisionDetailResponse(revision, commits)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error processing revision", utils.ErrChangeType, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Successfully get revision", nil, response)
+}
+
+// newRevisionDetailResponse strips the private user data from the revision and its commits
+func newRevisionDetailResponse(revision models.CourseRevision, commits []models.RevisionCommit) (revisionDetailResponse, error) {
+	response := revisionDetailResponse{Commits: make([]revisionCommitResponse, 0, len(commits))}
+
+	if revision.Editor != nil {
+		response.Editor = &models.UserPublicProfile{}
+		if err := copier.Copy(response.Editor, revision.Editor); err != nil {
+			return response, err
+		}
+	}
+	if revision.Approver != nil {
+		response.Approver = &models.UserPublicProfile{}
+		if err := copier.Copy(response.Approver, revision.Approver); err != nil {
+			return response, err
+		}
+	}
+
+	for _, commit := range commits {
+		commitResponse := revisionCommitResponse{}
+		if commit.Author != nil {
+			commitResponse.Author = &models.UserPublicProfile{}
+			if err := copier.Copy(commitResponse.Author, commit.Author); err != nil {
+				return response, err
+			}
+		}
+		commit.Author = nil
+		commitResponse.RevisionCommit = commit
+		response.Commits = append(response.Commits, commitResponse)
+	}
+
+	revision.Editor = nil
+	revision.Approver = nil
+	response.CourseRevision = revision
+
+	return response, nil
+}
+
+// getCourseRevision fetches the revision from the URL, the response is already written when ok is false
+func (h *Handler) getCourseRevision(c *gin.Context, courseID uint64) (revision models.CourseRevision, ok bool) {
+	revisionID, err := utils.StrToUint64(c.Param("revisionID"))
+	if err != nil {
+		utils.FullyResponse(c, 400, "Invalid revision ID", utils.ErrBadRequest, nil)
+		return revision, false
+	}
+
+	revision, result := queries.GetCourseRevisionInformation(h.DB.WithContext(c), courseID, revisionID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 404, "Revision not found", utils.ErrRivisionNotExist, nil)
+		return revision, false
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revision", utils.ErrGetData, result.Error)
+		return revision, false
+	}
+
+	return revision, true
+}
+
+// ensureRevisionReady checks the branch and pull request of the revision were created, the response is already written when they were not
+func ensureRevisionReady(c *gin.Context, revision models.CourseRevision) bool {
+	if revision.PullRequestID == 0 {
+		utils.FullyResponse(c, 409, "Revision is still being created, try again later", utils.ErrRevisionNotReady, nil)
+		return false
+	}
+	return true
+}
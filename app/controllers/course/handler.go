@@ -0,0 +1,13 @@
+package courses
+
+import "github.com/instructhub/backend/app"
+
+// Handler serves the course endpoints with the services of the App
+type Handler struct {
+	*app.App
+}
+
+// NewHandler creates the course handler
+func NewHandler(app *app.App) *Handler {
+	return &Handler{App: app}
+}
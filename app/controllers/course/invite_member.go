@@ -0,0 +1,180 @@
+package courses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// courseInvitationExpires is how long a pending invitation stays valid
+const courseInvitationExpires = 7 * 24 * time.Hour
+
+// inviteMemberRequest is the type for the request body of inviting a course member.
+type inviteMemberRequest struct {
+	Username string `json:"username" binding:"required_without=Email,max=32"`
+	Email    string `json:"email" binding:"required_without=Username,omitempty,email,max=320"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// courseInvitation is the pending invitation stored in Redis.
+type courseInvitation struct {
+	CourseID  uint64            `json:"course_id,string"`
+	UserID    uint64            `json:"user_id,string"`
+	InviterID uint64            `json:"inviter_id,string"`
+	Role      models.CourseRole `json:"role"`
+}
+
+// InviteCourseMember invites a user to the course by username or email
+func (h *Handler) InviteCourseMember(c *gin.Context) {
+	var request inviteMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	inviterID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+	course := c.MustGet("course").(models.Course)
+	inviterRole, _ := utils.GetCourseRoleFromContext(c)
+
+	role, ok := models.ParseStringToCourseRole(request.Role)
+	if !ok {
+		utils.FullyResponse(c, 400, "Invalid role", utils.ErrInvalidCourseRole, nil)
+		return
+	}
+	// Maintainers can only invite roles below their own, owners can invite everyone
+	if inviterRole != models.CourseOwner && role >= inviterRole {
+		utils.FullyResponse(c, 403, "You can't grant a role equal to or higher than yours", utils.ErrPermissionDenied, nil)
+		return
+	}
+
+	// Find the invited user
+	var invitee models.User
+	var result *gorm.DB
+	if request.Username != "" {
+		invitee, result = queries.GetUserQueueByUsername(h.DB.WithContext(c), request.Username)
+	} else {
+		invitee, result = queries.GetUserQueueByEmail(h.DB.WithContext(c), request.Email)
+	}
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 404, "User not found", utils.ErrUserNotFound, nil)
+		return
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching user", utils.ErrGetData, result.Error)
+		return
+	}
+
+	// Check the user is not a member yet
+	_, result = queries.GetCourseMember(h.DB.WithContext(c), course.ID, invitee.ID)
+	if result.Error == nil || invitee.ID == inviterID {
+		utils.FullyResponse(c, 400, "User is already a course member", utils.ErrAlreadyCourseMember, nil)
+		return
+	} else if result.Error != gorm.ErrRecordNotFound {
+		utils.ServerErrorResponse(c, 500, "Error fetching course member", utils.ErrGetData, result.Error)
+		return
+	}
+
+	// Courses created before memberships existed need their owner saved before anyone else joins
+	if err := h.ensureCourseOwner(c, course); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error saving course owner", utils.ErrSaveData, err)
+		return
+	}
+
+	inviter, result := queries.GetUserQueueByID(h.DB.WithContext(c), inviterID)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching user", utils.ErrGetData, result.Error)
+		return
+	}
+
+	// Store the pending invitation in Redis (with expiration)
+	inviteKey, err := encryption.GenerateRandomBase64String(64)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generating invitation key", utils.ErrGenerateToken, err)
+		return
+	}
+	invitation, err := json.Marshal(courseInvitation{
+		CourseID:  course.ID,
+		UserID:    invitee.ID,
+		InviterID: inviterID,
+		Role:      role,
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error encoding invitation", utils.ErrParseData, err)
+		return
+	}
+	if err := h.Cache.Set(c, courseInvitationKey(inviteKey), invitation, courseInvitationExpires).Err(); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error storing invitation", utils.ErrStoreRedis, err)
+		return
+	}
+
+	// Send the invitation email
+	if err := h.sendInvitationEmail(course, inviter, invitee, role, inviteKey); err != nil {
+		h.Cache.Del(c, courseInvitationKey(inviteKey))
+		utils.ServerErrorResponse(c, 500, "Error sending invitation email", utils.ErrSendEmail, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Invitation successfully sent", nil, nil)
+}
+
+// courseInvitationKey returns the Redis key of a pending invitation
+func courseInvitationKey(inviteKey string) string {
+	return "course_invitation:" + inviteKey
+}
+
+// ensureCourseOwner saves the course creator as owner if the course has no owner yet
+func (h *Handler) ensureCourseOwner(ctx context.Context, course models.Course) error {
+	owners, result := queries.CountCourseMembersByRole(h.DB.WithContext(ctx), course.ID, models.CourseOwner)
+	if result.Error != nil {
+		return result.Error
+	}
+	if owners > 0 {
+		return nil
+	}
+	return saveCourseOwner(h.DB.WithContext(ctx), course)
+}
+
+// sendInvitationEmail renders and sends the invitation email to the invited user
+func (h *Handler) sendInvitationEmail(course models.Course, inviter, invitee models.User, role models.CourseRole, inviteKey string) error {
+	data := struct {
+		InviteURL   string
+		UserName    string
+		InviterName string
+		CourseName  string
+		Role        string
+		ExpiresIn   string
+	}{
+		InviteURL:   fmt.Sprintf("%s/courses/%s/invitations/%s", utils.FrontendURl, utils.Uint64ToStr(course.ID), inviteKey),
+		UserName:    invitee.Username,
+		InviterName: inviter.DisplayName,
+		CourseName:  course.Name,
+		Role:        role.String(),
+		ExpiresIn:   "7 days",
+	}
+
+	var emailBody bytes.Buffer
+	t, err := template.New("Course invitation").ParseFiles("template/course_invitation.html")
+	if err != nil {
+		return err
+	}
+
+	err = t.ExecuteTemplate(&emailBody, "course_invitation.html", data)
+	if err != nil {
+		return err
+	}
+
+	return h.Mailer.Send(invitee.Email, "You are invited to join "+course.Name, emailBody.String())
+}
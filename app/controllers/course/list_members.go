@@ -0,0 +1,48 @@
+package courses
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// courseMemberResponse is the public data of a course member
+type courseMemberResponse struct {
+	UserID      uint64            `json:"user_id,string"`
+	Username    string            `json:"username"`
+	DisplayName string            `json:"display_name"`
+	Avatar      *string           `json:"avatar,omitempty"`
+	Role        models.CourseRole `json:"role"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// ListCourseMembers handles listing the members of a course and their roles
+func (h *Handler) ListCourseMembers(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+
+	members, result := queries.GetCourseMembers(h.DB.WithContext(c), course.ID)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching course members", utils.ErrGetData, result.Error)
+		return
+	}
+
+	response := make([]courseMemberResponse, 0, len(members))
+	for _, member := range members {
+		if member.User == nil {
+			continue
+		}
+		response = append(response, courseMemberResponse{
+			UserID:      member.UserID,
+			Username:    member.User.Username,
+			DisplayName: member.User.DisplayName,
+			Avatar:      member.User.Avatar,
+			Role:        member.Role,
+			CreatedAt:   member.CreatedAt,
+		})
+	}
+
+	utils.FullyResponse(c, 200, "Successfully get course members", nil, response)
+}
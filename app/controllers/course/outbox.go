@@ -0,0 +1,229 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/app/workers"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/tracing"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// OutboxHandlers returns the handlers of the Gitea side effects saved by the course controllers
+func (h *Handler) OutboxHandlers() map[models.OutboxEventType]workers.OutboxHandler {
+	return map[models.OutboxEventType]workers.OutboxHandler{
+		models.OutboxCreateCourseRepo: h.handleCreateCourseRepo,
+		models.OutboxCreateRevision:   h.handleCreateRevision,
+		models.OutboxMirrorComment:    h.handleMirrorComment,
+		models.OutboxMirrorReview:     h.handleMirrorReview,
+	}
+}
+
+// enqueueOutboxEvent saves an event in the transaction of the data it belongs to,
+// the key only needs to be unique for the event type. The trace of the context of tx
+// is saved with it so the worker continues it
+func enqueueOutboxEvent(tx *gorm.DB, eventType models.OutboxEventType, key string, payload interface{}) error {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	traceContext, err := json.Marshal(tracing.Inject(tx.Statement.Context))
+	if err != nil {
+		return err
+	}
+
+	result := queries.CreateOutboxEvent(tx, models.OutboxEvent{
+		ID:             encryption.GenerateID(),
+		Type:           eventType,
+		IdempotencyKey: models.OutboxIdempotencyKey(eventType, key),
+		Payload:        string(encodedPayload),
+		TraceContext:   string(traceContext),
+		Status:         models.OutboxPending,
+		NextAttemptAt:  time.Now(),
+		UpdatedAt:      time.Now(),
+		CreatedAt:      time.Now(),
+	})
+	return result.Error
+}
+
+// handleCreateCourseRepo creates the repository of a new course and its course data file
+func (h *Handler) handleCreateCourseRepo(ctx context.Context, event models.OutboxEvent) error {
+	var payload models.CourseRepoPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return err
+	}
+
+	exists, err := h.Content.RepoExists(ctx, utils.Uint64ToStr(payload.CourseID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := h.createCourseRepo(ctx, payload.CourseID); err != nil {
+			return err
+		}
+	}
+
+	_, exists, err = h.fetchGitFile(ctx, payload.CourseID, defaultBranch, models.CourseDataFile)
+	if err != nil || exists {
+		return err
+	}
+	return h.createCourseFile(ctx, payload.CourseID, payload.UserID)
+}
+
+// handleCreateRevision commits the changes of a new revision on its branch and opens its pull request
+func (h *Handler) handleCreateRevision(ctx context.Context, event models.OutboxEvent) error {
+	var payload models.RevisionCreatePayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return err
+	}
+
+	revision, result := queries.GetCourseRevisionInformation(h.DB.WithContext(ctx), payload.CourseID, payload.RevisionID)
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil
+	} else if result.Error != nil {
+		return result.Error
+	}
+	if revision.PullRequestID != 0 {
+		return nil
+	}
+
+	repoName := utils.Uint64ToStr(payload.CourseID)
+	branchName := utils.Uint64ToStr(payload.BranchID)
+
+	// The branch is already there when a previous attempt failed after the commit
+	commit, err := h.Content.GetBranch(ctx, repoName, branchName)
+	if errors.Is(err, content.ErrNotFound) {
+		commit, err = h.commitCourseChanges(ctx, payload.CourseID, fromOutboxFiles(payload.Files), payload.Message, payload.CourseData, payload.UserID, content.CommitRequest{
+			Branch:    defaultBranch,
+			NewBranch: branchName,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	changeRequest, err := h.findOrOpenChangeRequest(ctx, repoName, branchName, revision.Description)
+	if err != nil {
+		return err
+	}
+
+	// Record the first commit before the pull request, which marks the revision as ready
+	commits, result := queries.GetRevisionCommits(h.DB.WithContext(ctx), revision.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if len(commits) == 0 {
+		result = queries.CreateRevisionCommit(h.DB.WithContext(ctx), models.RevisionCommit{
+			ID:         encryption.GenerateID(),
+			RevisionID: revision.ID,
+			SHA:        commit.SHA,
+			ParentSHA:  commit.Parent(),
+			AuthorID:   payload.UserID,
+			Message:    payload.Message,
+			CreatedAt:  time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	revision.PullRequestID = int(changeRequest.Index)
+	revision.BaseCommit = commit.Parent()
+	revision.UpdatedAt = time.Now()
+	return queries.UpdateCourseRevision(h.DB.WithContext(ctx), revision).Error
+}
+
+// findOrOpenChangeRequest returns the open change request of the branch, opening it when there is none
+func (h *Handler) findOrOpenChangeRequest(ctx context.Context, repoName, branchName, title string) (content.ChangeRequest, error) {
+	changeRequest, err := h.Content.FindChangeRequest(ctx, repoName, branchName)
+	if !errors.Is(err, content.ErrNotFound) {
+		return changeRequest, err
+	}
+	return h.Content.OpenChangeRequest(ctx, repoName, branchName, defaultBranch, title)
+}
+
+// handleMirrorComment copies a comment on the pull request of its revision
+func (h *Handler) handleMirrorComment(ctx context.Context, event models.OutboxEvent) error {
+	var payload models.CommentMirrorPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return err
+	}
+
+	comment, result := queries.GetRevisionComment(h.DB.WithContext(ctx), payload.RevisionID, payload.CommentID)
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil
+	} else if result.Error != nil {
+		return result.Error
+	}
+	if comment.GiteaCommentID != nil {
+		return nil
+	}
+
+	revision, err := h.getReadyRevision(ctx, payload.RevisionID)
+	if err != nil {
+		return err
+	}
+	return h.mirrorCommentToGitea(ctx, revision, comment)
+}
+
+// handleMirrorReview copies a review on the pull request of its revision
+func (h *Handler) handleMirrorReview(ctx context.Context, event models.OutboxEvent) error {
+	var payload models.ReviewMirrorPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return err
+	}
+
+	review, result := queries.GetRevisionReview(h.DB.WithContext(ctx), payload.RevisionID, payload.ReviewID)
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil
+	} else if result.Error != nil {
+		return result.Error
+	}
+	if review.GiteaReviewID != nil {
+		return nil
+	}
+
+	revision, err := h.getReadyRevision(ctx, payload.RevisionID)
+	if err != nil {
+		return err
+	}
+	return h.mirrorReviewToGitea(ctx, revision, review)
+}
+
+// getReadyRevision fetches a revision whose pull request was already created
+func (h *Handler) getReadyRevision(ctx context.Context, revisionID uint64) (models.CourseRevision, error) {
+	revision, result := queries.GetCourseRevisionByID(h.DB.WithContext(ctx), revisionID)
+	if result.Error != nil {
+		return revision, result.Error
+	}
+	if revision.PullRequestID == 0 {
+		return revision, fmt.Errorf("pull request of revision %d is not created yet", revisionID)
+	}
+	return revision, nil
+}
+
+// toOutboxFiles converts file changes to be saved in an outbox event
+func toOutboxFiles(files []content.File) []models.OutboxFile {
+	outboxFiles := make([]models.OutboxFile, 0, len(files))
+	for _, file := range files {
+		outboxFiles = append(outboxFiles, models.OutboxFile{Path: file.Path, Content: file.Content, Operation: string(file.Operation)})
+	}
+	return outboxFiles
+}
+
+// fromOutboxFiles converts the file changes of an outbox event back
+func fromOutboxFiles(outboxFiles []models.OutboxFile) []content.File {
+	files := make([]content.File, 0, len(outboxFiles))
+	for _, file := range outboxFiles {
+		files = append(files, content.File{Path: file.Path, Content: file.Content, Operation: content.Operation(file.Operation)})
+	}
+	return files
+}
@@ -0,0 +1,171 @@
+package courses
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// RebaseRevision handles moving a stale revision on top of the current course
+func (h *Handler) RebaseRevision(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok || !ensureRevisionReady(c, revision) {
+		return
+	}
+
+	// Only the editor and maintainers can rebase a revision
+	role, isMember := utils.GetCourseRoleFromContext(c)
+	if revision.EditorID != userID && !(isMember && role >= models.CourseMaintainer) {
+		utils.FullyResponse(c, 403, "You don't have permission to rebase this revision", utils.ErrPermissionDenied, nil)
+		return
+	}
+
+	if revision.Status == models.RevisionLock {
+		utils.FullyResponse(c, 403, "Revision is locked", utils.ErrRevisionLocked, nil)
+		return
+	} else if revision.Status != models.RevisionOpen {
+		utils.FullyResponse(c, 400, "Only open revisions can be rebased", utils.ErrRevisionNotOpen, nil)
+		return
+	}
+
+	revision, report, err := h.rebaseRevision(c, revision, userID)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error rebasing revision", utils.ErrSaveCourseFile, err)
+		return
+	}
+	if report != nil {
+		utils.FullyResponse(c, 409, "Revision conflicts with the current course", utils.ErrRevisionConflict, report)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Revision successfully rebased", nil, revision)
+}
+
+// rebaseRevision replays the changes of the revision on top of the current base branch when the base branch moved,
+// the report is set instead when the changes can't be merged automatically
+func (h *Handler) rebaseRevision(ctx context.Context, revision models.CourseRevision, userID uint64) (models.CourseRevision, *revisionConflictReport, error) {
+	repoName := utils.Uint64ToStr(revision.CourseID)
+
+	baseCommit, err := h.revisionBaseCommit(ctx, revision)
+	if err != nil {
+		return revision, nil, err
+	}
+
+	branch, err := h.Content.GetBranch(ctx, repoName, defaultBranch)
+	if err != nil {
+		return revision, nil, err
+	}
+	currentCommit := branch.SHA
+	if currentCommit == baseCommit {
+		return revision, nil, nil
+	}
+
+	// Three-way merge between the old base, the current base and the revision
+	base, err := h.loadCourseSnapshot(ctx, revision.CourseID, baseCommit)
+	if err != nil {
+		return revision, nil, err
+	}
+	current, err := h.loadCourseSnapshot(ctx, revision.CourseID, currentCommit)
+	if err != nil {
+		return revision, nil, err
+	}
+	head, err := h.loadCourseSnapshot(ctx, revision.CourseID, utils.Uint64ToStr(revision.BranchID))
+	if err != nil {
+		return revision, nil, err
+	}
+
+	mergedData, updateFiles, conflicts, err := h.mergeCourseSnapshots(ctx, revision.CourseID, base, current, head)
+	if err != nil {
+		return revision, nil, err
+	}
+	if len(conflicts) > 0 {
+		return revision, &revisionConflictReport{
+			BaseCommit:    baseCommit,
+			CurrentCommit: currentCommit,
+			Conflicts:     conflicts,
+		}, nil
+	}
+
+	courseDataJson, err := encodeCourseData(mergedData)
+	if err != nil {
+		return revision, nil, err
+	}
+
+	// Commit the merged changes on a new branch started from the current base branch
+	newBranchID := encryption.GenerateID()
+	newBranch := utils.Uint64ToStr(newBranchID)
+	commit, err := h.commitCourseChanges(ctx, revision.CourseID, updateFiles, "Rebase onto "+currentCommit, courseDataJson, userID, content.CommitRequest{
+		Branch:    defaultBranch,
+		NewBranch: newBranch,
+	})
+	if err != nil {
+		return revision, nil, err
+	}
+	if commit.Parent() != currentCommit {
+		h.Content.DeleteBranch(ctx, repoName, newBranch)
+		return revision, nil, fmt.Errorf("base branch moved during the rebase")
+	}
+
+	changeRequest, err := h.Content.OpenChangeRequest(ctx, repoName, newBranch, defaultBranch, revision.Description)
+	if err != nil {
+		h.Content.DeleteBranch(ctx, repoName, newBranch)
+		return revision, nil, err
+	}
+
+	oldRevision := revision
+	revision.BranchID = newBranchID
+	revision.PullRequestID = int(changeRequest.Index)
+	revision.BaseCommit = currentCommit
+	revision.UpdatedAt = time.Now()
+	// A status change made meanwhile, like an approval claiming the revision, wins over this rebase
+	if result := queries.UpdateRevisionBranch(h.DB.WithContext(ctx), revision); result.Error != nil || result.RowsAffected == 0 {
+		h.setPullRequestState(ctx, revision, content.ChangeRequestClosed)
+		h.Content.DeleteBranch(ctx, repoName, newBranch)
+		if result.Error != nil {
+			return oldRevision, nil, result.Error
+		}
+		return oldRevision, nil, fmt.Errorf("revision %d changed during the rebase", revision.ID)
+	}
+	if result := queries.CreateRevisionCommit(h.DB.WithContext(ctx), newRevisionCommit(revision.ID, userID, commit)); result.Error != nil {
+		return revision, nil, result.Error
+	}
+
+	// The old pull request and branch are replaced by the new ones
+	if err := h.setPullRequestState(ctx, oldRevision, content.ChangeRequestClosed); err != nil {
+		return revision, nil, err
+	}
+	h.Content.DeleteBranch(ctx, repoName, utils.Uint64ToStr(oldRevision.BranchID))
+
+	return revision, nil, nil
+}
+
+// revisionBaseCommit returns the commit the revision branch started from,
+// revisions created before it was recorded fall back to the merge base of their pull request
+func (h *Handler) revisionBaseCommit(ctx context.Context, revision models.CourseRevision) (string, error) {
+	if revision.BaseCommit != "" {
+		return revision.BaseCommit, nil
+	}
+
+	changeRequest, err := h.Content.GetChangeRequest(ctx, utils.Uint64ToStr(revision.CourseID), int64(revision.PullRequestID))
+	if err != nil {
+		return "", err
+	}
+	if changeRequest.MergeBase == "" {
+		return "", fmt.Errorf("pull request %d has no merge base", revision.PullRequestID)
+	}
+	return changeRequest.MergeBase, nil
+}
@@ -0,0 +1,51 @@
+package courses
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// RemoveCourseMember handles removing a member from the course, members can also remove themselves
+func (h *Handler) RemoveCourseMember(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	course := c.MustGet("course").(models.Course)
+	member, ok := h.getTargetMember(c, course)
+	if !ok {
+		return
+	}
+
+	// Maintainers can only remove members below their own role, owners can remove everyone
+	actorRole, _ := utils.GetCourseRoleFromContext(c)
+	if member.UserID != userID && actorRole != models.CourseOwner && (actorRole < models.CourseMaintainer || member.Role >= actorRole) {
+		utils.FullyResponse(c, 403, "You don't have permission to do this", utils.ErrPermissionDenied, nil)
+		return
+	}
+
+	lastOwner := false
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		last, err := isLastOwner(tx, course.ID, member.UserID)
+		if err != nil || last {
+			lastOwner = last
+			return err
+		}
+		return queries.DeleteCourseMember(tx, course.ID, member.UserID).Error
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error removing course member", utils.ErrDeleteData, err)
+		return
+	}
+	if lastOwner {
+		utils.FullyResponse(c, 400, "A course needs at least one owner", utils.ErrLastCourseOwner, nil)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Successfully removed course member", nil, nil)
+}
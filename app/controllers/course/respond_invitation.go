@@ -0,0 +1,102 @@
+package courses
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// AcceptCourseInvitation adds the invited user to the course
+func (h *Handler) AcceptCourseInvitation(c *gin.Context) {
+	invitation, ok := h.getCourseInvitation(c)
+	if !ok {
+		return
+	}
+
+	// Skip creating the member if the user already joined the course some other way
+	_, result := queries.GetCourseMember(h.DB.WithContext(c), invitation.CourseID, invitation.UserID)
+	if result.Error == gorm.ErrRecordNotFound {
+		result = queries.CreateCourseMember(h.DB.WithContext(c), models.CourseMember{
+			ID:        encryption.GenerateID(),
+			CourseID:  invitation.CourseID,
+			UserID:    invitation.UserID,
+			Role:      invitation.Role,
+			UpdatedAt: time.Now(),
+			CreatedAt: time.Now(),
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			utils.ServerErrorResponse(c, 500, "Error saving course member", utils.ErrSaveData, result.Error)
+			return
+		}
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching course member", utils.ErrGetData, result.Error)
+		return
+	}
+
+	// Delete the invitation from Redis after it has been used
+	if err := h.Cache.Del(c, courseInvitationKey(c.Param("inviteKey"))).Err(); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error deleting invitation", utils.ErrDeleteData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Successfully joined the course", nil, nil)
+}
+
+// DeclineCourseInvitation deletes the invitation without joining the course
+func (h *Handler) DeclineCourseInvitation(c *gin.Context) {
+	if _, ok := h.getCourseInvitation(c); !ok {
+		return
+	}
+
+	if err := h.Cache.Del(c, courseInvitationKey(c.Param("inviteKey"))).Err(); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error deleting invitation", utils.ErrDeleteData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Invitation declined", nil, nil)
+}
+
+// getCourseInvitation fetches the invitation from Redis and checks it belongs to the current user and course,
+// the response is already written when ok is false
+func (h *Handler) getCourseInvitation(c *gin.Context) (invitation courseInvitation, ok bool) {
+	courseID, err := utils.StrToUint64(c.Param("courseID"))
+	if err != nil {
+		utils.FullyResponse(c, 400, "Invalid course ID", utils.ErrBadRequest, nil)
+		return invitation, false
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return invitation, false
+	}
+
+	data, err := h.Cache.Get(c, courseInvitationKey(c.Param("inviteKey"))).Result()
+	if err == redis.Nil {
+		utils.FullyResponse(c, 404, "Invitation not found or expired", utils.ErrInvitationNotExist, nil)
+		return invitation, false
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error accessing Redis", utils.ErrGetData, err)
+		return invitation, false
+	}
+
+	if err := json.Unmarshal([]byte(data), &invitation); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error unmarshaling invitation", utils.ErrUnmarshal, err)
+		return invitation, false
+	}
+
+	// Don't leak the existence of invitations sent to someone else
+	if invitation.CourseID != courseID || invitation.UserID != userID {
+		utils.FullyResponse(c, 404, "Invitation not found or expired", utils.ErrInvitationNotExist, nil)
+		return invitation, false
+	}
+
+	return invitation, true
+}
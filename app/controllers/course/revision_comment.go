@@ -0,0 +1,210 @@
+package courses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/app/workers"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
+)
+
+// createCommentRequest is the type for the request body of commenting on a revision.
+type createCommentRequest struct {
+	Body     string  `json:"body" binding:"required,max=5000"`
+	ParentID *string `json:"parent_id" binding:"omitempty,number"`
+	StepID   *string `json:"step_id" binding:"omitempty,number"`
+}
+
+// revisionCommentResponse is a comment with the public data of its author and its replies
+type revisionCommentResponse struct {
+	models.RevisionComment
+	Author  *models.UserPublicProfile  `json:"author,omitempty"`
+	Replies []*revisionCommentResponse `json:"replies"`
+}
+
+// ListRevisionComments handles listing the comment threads of a revision
+func (h *Handler) ListRevisionComments(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok {
+		return
+	}
+
+	comments, result := queries.GetRevisionComments(h.DB.WithContext(c), revision.ID)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching comments", utils.ErrGetData, result.Error)
+		return
+	}
+
+	threads, err := buildCommentThreads(comments)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error processing comments", utils.ErrChangeType, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Successfully get comments", nil, threads)
+}
+
+// CreateRevisionComment handles commenting on a revision, optionally on one of its steps or as a reply
+func (h *Handler) CreateRevisionComment(c *gin.Context) {
+	var request createCommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok {
+		return
+	}
+
+	if revision.Status == models.RevisionLock {
+		utils.FullyResponse(c, 403, "Revision is locked", utils.ErrRevisionLocked, nil)
+		return
+	}
+
+	comment := models.RevisionComment{
+		ID:         encryption.GenerateID(),
+		RevisionID: revision.ID,
+		AuthorID:   userID,
+		Body:       request.Body,
+		UpdatedAt:  time.Now(),
+		CreatedAt:  time.Now(),
+	}
+
+	// Replies stay on the step of the comment they answer
+	if request.ParentID != nil {
+		parent, result := queries.GetRevisionComment(h.DB.WithContext(c), revision.ID, utils.StrToUint64NoError(*request.ParentID))
+		if result.Error == gorm.ErrRecordNotFound {
+			utils.FullyResponse(c, 404, "Parent comment not found", utils.ErrCommentNotExist, nil)
+			return
+		} else if result.Error != nil {
+			utils.ServerErrorResponse(c, 500, "Error fetching comment", utils.ErrGetData, result.Error)
+			return
+		}
+		comment.ParentID = &parent.ID
+		comment.StepID = parent.StepID
+	} else if request.StepID != nil {
+		exists, err := h.revisionHasStep(c, revision, *request.StepID)
+		if err != nil {
+			utils.ServerErrorResponse(c, 500, "Error fetching revision data", utils.ErrGetData, err)
+			return
+		}
+		if !exists {
+			utils.FullyResponse(c, 400, "Step doesn't exist in this revision", utils.ErrBadRequest, nil)
+			return
+		}
+		stepID := utils.StrToUint64NoError(*request.StepID)
+		comment.StepID = &stepID
+	}
+
+	// The comment is mirrored on the pull request by the outbox worker
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := queries.CreateRevisionComment(tx, comment)
+		if result.Error != nil {
+			return result.Error
+		}
+		return enqueueOutboxEvent(tx, models.OutboxMirrorComment, utils.Uint64ToStr(comment.ID), models.CommentMirrorPayload{
+			RevisionID: revision.ID,
+			CommentID:  comment.ID,
+		})
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error saving comment", utils.ErrSaveData, err)
+		return
+	}
+	workers.NotifyOutbox()
+
+	utils.FullyResponse(c, 201, "Successfully created comment", nil, comment)
+}
+
+// revisionHasStep checks if the step exists in the course data of the revision branch
+func (h *Handler) revisionHasStep(ctx context.Context, revision models.CourseRevision, stepID string) (bool, error) {
+	courseData, err := h.fetchCourseData(ctx, revision.CourseID, utils.Uint64ToStr(revision.BranchID))
+	if err != nil {
+		return false, err
+	}
+	_, _, steps, _ := indexCourseData(courseData)
+	_, exists := steps[stepID]
+	return exists, nil
+}
+
+// buildCommentThreads nests the replies under the comment they answer
+func buildCommentThreads(comments []models.RevisionComment) ([]*revisionCommentResponse, error) {
+	byID := map[uint64]*revisionCommentResponse{}
+	threads := []*revisionCommentResponse{}
+
+	for _, comment := range comments {
+		response := &revisionCommentResponse{Replies: []*revisionCommentResponse{}}
+		if comment.Author != nil {
+			response.Author = &models.UserPublicProfile{}
+			if err := copier.Copy(response.Author, comment.Author); err != nil {
+				return nil, err
+			}
+		}
+		comment.Author = nil
+		response.RevisionComment = comment
+		byID[comment.ID] = response
+	}
+
+	// Comments are sorted by creation time so a parent always comes before its replies
+	for _, comment := range comments {
+		response := byID[comment.ID]
+		if comment.ParentID != nil {
+			if parent, ok := byID[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, response)
+				continue
+			}
+		}
+		threads = append(threads, response)
+	}
+
+	return threads, nil
+}
+
+// mirrorCommentToGitea copies the comment on the pull request of the revision
+func (h *Handler) mirrorCommentToGitea(ctx context.Context, revision models.CourseRevision, comment models.RevisionComment) error {
+	author, result := queries.GetUserQueueByID(h.DB.WithContext(ctx), comment.AuthorID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	body := fmt.Sprintf("**@%s** commented", author.Username)
+	if comment.StepID != nil {
+		body += fmt.Sprintf(" on step `%s`", utils.Uint64ToStr(*comment.StepID))
+	}
+	if comment.ParentID != nil {
+		body += fmt.Sprintf(" in reply to comment `%s`", utils.Uint64ToStr(*comment.ParentID))
+	}
+	body += ":\n\n" + comment.Body
+
+	// A retry after the comment was posted but not recorded finds it by its marker instead of posting it twice
+	repoName := utils.Uint64ToStr(revision.CourseID)
+	marker := fmt.Sprintf("<!-- revision-comment:%s -->", utils.Uint64ToStr(comment.ID))
+	giteaCommentID, err := h.Content.FindComment(ctx, repoName, int64(revision.PullRequestID), marker)
+	if errors.Is(err, content.ErrNotFound) {
+		giteaCommentID, err = h.Content.Comment(ctx, repoName, int64(revision.PullRequestID), body+"\n\n"+marker)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mirror comment on pull request: %w", err)
+	}
+
+	result = queries.UpdateRevisionCommentGiteaID(h.DB.WithContext(ctx), comment.ID, giteaCommentID)
+	return result.Error
+}
@@ -0,0 +1,320 @@
+package courses
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/pkg/diff"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+type changeType string
+
+const (
+	changeAdded     changeType = "added"
+	changeRemoved   changeType = "removed"
+	changeRenamed   changeType = "renamed"
+	changeReordered changeType = "reordered"
+	changeMoved     changeType = "moved"
+	changeModified  changeType = "modified"
+)
+
+// moduleDiff describes how a module changed in a revision
+type moduleDiff struct {
+	ID          string       `json:"id"`
+	Changes     []changeType `json:"changes"`
+	Name        string       `json:"name"`
+	OldName     string       `json:"old_name,omitempty"`
+	Position    int          `json:"position,omitempty"`
+	OldPosition int          `json:"old_position,omitempty"`
+}
+
+// stepDiff describes how a step changed in a revision
+type stepDiff struct {
+	ID          string       `json:"id"`
+	Changes     []changeType `json:"changes"`
+	Name        string       `json:"name"`
+	OldName     string       `json:"old_name,omitempty"`
+	ModuleID    string       `json:"module_id,omitempty"`
+	OldModuleID string       `json:"old_module_id,omitempty"`
+	Position    int          `json:"position,omitempty"`
+	OldPosition int          `json:"old_position,omitempty"`
+	Content     []diff.Line  `json:"content,omitempty"`
+}
+
+// revisionDiff is the structural diff between a revision and the commit it started from
+type revisionDiff struct {
+	Modules []moduleDiff `json:"modules"`
+	Steps   []stepDiff   `json:"steps"`
+}
+
+// GetRevisionDiff handles comparing the course data of a revision against the commit it started from
+func (h *Handler) GetRevisionDiff(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok || !ensureRevisionReady(c, revision) {
+		return
+	}
+	if revision.BranchDeleted {
+		utils.FullyResponse(c, 410, "Revision branch was already deleted", utils.ErrRevisionBranchDeleted, nil)
+		return
+	}
+
+	headRef := utils.Uint64ToStr(revision.BranchID)
+
+	// Compare with the commit the revision started from, not with what was merged into the course since
+	baseRef, err := h.revisionBaseCommit(c, revision)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revision base", utils.ErrGetData, err)
+		return
+	}
+
+	// Fetch both versions of the course data
+	oldCourseData, err := h.fetchCourseData(c, course.ID, baseRef)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching course data", utils.ErrGetData, err)
+		return
+	}
+	newCourseData, err := h.fetchCourseData(c, course.ID, headRef)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revision data", utils.ErrGetData, err)
+		return
+	}
+
+	modules, steps := diffCourseStructure(oldCourseData, newCourseData)
+
+	// Only the step files touched by the pull request need a content diff
+	changedFiles, err := h.listRevisionChangedFiles(c, revision)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching changed files", utils.ErrGetData, err)
+		return
+	}
+
+	steps, err = h.diffStepContents(c, course.ID, baseRef, headRef, steps, changedFiles)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error comparing step content", utils.ErrGetData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Successfully get revision diff", nil, revisionDiff{
+		Modules: modules,
+		Steps:   steps,
+	})
+}
+
+// stepLocation is a step together with the module it belongs to
+type stepLocation struct {
+	step     CourseStepRequest
+	moduleID string
+}
+
+// diffCourseStructure reports added, removed, renamed and reordered modules and steps
+func diffCourseStructure(oldData, newData UpdateRequestCourse) ([]moduleDiff, []stepDiff) {
+	oldModules, oldModuleOrder, oldSteps, oldStepOrder := indexCourseData(oldData)
+	newModules, newModuleOrder, newSteps, newStepOrder := indexCourseData(newData)
+
+	modules := []moduleDiff{}
+	movedModules := reorderedIDs(oldModuleOrder, newModuleOrder)
+	for _, id := range newModuleOrder {
+		newModule := newModules[id]
+		oldModule, ok := oldModules[id]
+		if !ok {
+			modules = append(modules, moduleDiff{ID: id, Changes: []changeType{changeAdded}, Name: newModule.Name, Position: newModule.Position})
+			continue
+		}
+
+		changes := []changeType{}
+		if oldModule.Name != newModule.Name {
+			changes = append(changes, changeRenamed)
+		}
+		if movedModules[id] {
+			changes = append(changes, changeReordered)
+		}
+		if len(changes) > 0 {
+			modules = append(modules, moduleDiff{
+				ID:          id,
+				Changes:     changes,
+				Name:        newModule.Name,
+				OldName:     oldModule.Name,
+				Position:    newModule.Position,
+				OldPosition: oldModule.Position,
+			})
+		}
+	}
+	for _, id := range oldModuleOrder {
+		if _, ok := newModules[id]; !ok {
+			oldModule := oldModules[id]
+			modules = append(modules, moduleDiff{ID: id, Changes: []changeType{changeRemoved}, Name: oldModule.Name, OldPosition: oldModule.Position})
+		}
+	}
+
+	// Steps are reordered when they lost their place inside the same module
+	movedSteps := map[string]bool{}
+	for moduleID, order := range newStepOrder {
+		for id := range reorderedIDs(oldStepOrder[moduleID], order) {
+			movedSteps[id] = true
+		}
+	}
+
+	steps := []stepDiff{}
+	for _, moduleID := range newModuleOrder {
+		for _, id := range newStepOrder[moduleID] {
+			newStep := newSteps[id]
+			oldStep, ok := oldSteps[id]
+			if !ok {
+				steps = append(steps, stepDiff{ID: id, Changes: []changeType{changeAdded}, Name: newStep.step.Name, ModuleID: moduleID, Position: newStep.step.Position})
+				continue
+			}
+
+			changes := []changeType{}
+			if oldStep.step.Name != newStep.step.Name {
+				changes = append(changes, changeRenamed)
+			}
+			if oldStep.moduleID != newStep.moduleID {
+				changes = append(changes, changeMoved)
+			} else if movedSteps[id] {
+				changes = append(changes, changeReordered)
+			}
+			// Unchanged steps are kept so their content can still be compared
+			steps = append(steps, stepDiff{
+				ID:          id,
+				Changes:     changes,
+				Name:        newStep.step.Name,
+				OldName:     oldStep.step.Name,
+				ModuleID:    moduleID,
+				OldModuleID: oldStep.moduleID,
+				Position:    newStep.step.Position,
+				OldPosition: oldStep.step.Position,
+			})
+		}
+	}
+	for _, moduleID := range oldModuleOrder {
+		for _, id := range oldStepOrder[moduleID] {
+			if _, ok := newSteps[id]; !ok {
+				oldStep := oldSteps[id]
+				steps = append(steps, stepDiff{ID: id, Changes: []changeType{changeRemoved}, Name: oldStep.step.Name, OldModuleID: moduleID, OldPosition: oldStep.step.Position})
+			}
+		}
+	}
+
+	return modules, steps
+}
+
+// indexCourseData maps the modules and steps of the course data by ID and keeps their order
+func indexCourseData(courseData UpdateRequestCourse) (map[string]CourseModuleRequest, []string, map[string]stepLocation, map[string][]string) {
+	modules := map[string]CourseModuleRequest{}
+	moduleOrder := []string{}
+	steps := map[string]stepLocation{}
+	stepOrder := map[string][]string{}
+
+	for _, module := range courseData.Modules {
+		if module.ID == nil {
+			continue
+		}
+		modules[*module.ID] = module
+		moduleOrder = append(moduleOrder, *module.ID)
+
+		for _, step := range module.CourseSteps {
+			if step.ID == nil {
+				continue
+			}
+			steps[*step.ID] = stepLocation{step: step, moduleID: *module.ID}
+			stepOrder[*module.ID] = append(stepOrder[*module.ID], *step.ID)
+		}
+	}
+
+	return modules, moduleOrder, steps, stepOrder
+}
+
+// reorderedIDs finds the IDs present in both orders that are not part of their longest common order
+func reorderedIDs(oldOrder, newOrder []string) map[string]bool {
+	inOld := map[string]bool{}
+	for _, id := range oldOrder {
+		inOld[id] = true
+	}
+	inNew := map[string]bool{}
+	for _, id := range newOrder {
+		inNew[id] = true
+	}
+
+	commonOld := []string{}
+	for _, id := range oldOrder {
+		if inNew[id] {
+			commonOld = append(commonOld, id)
+		}
+	}
+	commonNew := []string{}
+	for _, id := range newOrder {
+		if inOld[id] {
+			commonNew = append(commonNew, id)
+		}
+	}
+
+	stable := map[string]bool{}
+	for _, id := range diff.LongestCommonSubsequence(commonOld, commonNew) {
+		stable[id] = true
+	}
+
+	reordered := map[string]bool{}
+	for _, id := range commonNew {
+		if !stable[id] {
+			reordered[id] = true
+		}
+	}
+	return reordered
+}
+
+// listRevisionChangedFiles lists the files changed by the pull request of the revision
+func (h *Handler) listRevisionChangedFiles(ctx context.Context, revision models.CourseRevision) (map[string]bool, error) {
+	paths, err := h.Content.ChangedFiles(ctx, utils.Uint64ToStr(revision.CourseID), int64(revision.PullRequestID))
+	if err != nil {
+		return nil, err
+	}
+
+	changedFiles := map[string]bool{}
+	for _, path := range paths {
+		changedFiles[path] = true
+	}
+	return changedFiles, nil
+}
+
+// diffStepContents adds a line diff to every step whose file was changed by the revision
+func (h *Handler) diffStepContents(ctx context.Context, courseID uint64, baseRef, headRef string, steps []stepDiff, changedFiles map[string]bool) ([]stepDiff, error) {
+	result := make([]stepDiff, 0, len(steps))
+
+	for _, step := range steps {
+		if !changedFiles[step.ID] {
+			if len(step.Changes) > 0 {
+				result = append(result, step)
+			}
+			continue
+		}
+
+		oldContent, _, err := h.fetchGitFile(ctx, courseID, baseRef, step.ID)
+		if err != nil {
+			return nil, err
+		}
+		newContent, _, err := h.fetchGitFile(ctx, courseID, headRef, step.ID)
+		if err != nil {
+			return nil, err
+		}
+		if oldContent == newContent {
+			if len(step.Changes) > 0 {
+				result = append(result, step)
+			}
+			continue
+		}
+
+		step.Content = diff.Text(oldContent, newContent)
+		if len(step.Changes) == 0 {
+			step.Changes = []changeType{changeModified}
+		} else if step.Changes[0] != changeAdded && step.Changes[0] != changeRemoved {
+			step.Changes = append(step.Changes, changeModified)
+		}
+		result = append(result, step)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,384 @@
+package courses
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/diff"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+type conflictKind string
+
+const (
+	conflictModule  conflictKind = "module"
+	conflictStep    conflictKind = "step"
+	conflictContent conflictKind = "content"
+	conflictOrder   conflictKind = "order"
+)
+
+// mergeConflict describes a change of the revision that can't be applied on the current course
+type mergeConflict struct {
+	Kind     conflictKind    `json:"kind"`
+	ID       string          `json:"id"`
+	Field    string          `json:"field,omitempty"`
+	Message  string          `json:"message"`
+	Base     string          `json:"base,omitempty"`
+	Current  string          `json:"current,omitempty"`
+	Revision string          `json:"revision,omitempty"`
+	Content  []diff.Conflict `json:"content,omitempty"`
+}
+
+// revisionConflictReport is returned to the editor when a revision can't be rebased automatically
+type revisionConflictReport struct {
+	BaseCommit    string          `json:"base_commit"`
+	CurrentCommit string          `json:"current_commit"`
+	Conflicts     []mergeConflict `json:"conflicts"`
+}
+
+// courseSnapshot is the course data and the blob SHA of every file at a git ref
+type courseSnapshot struct {
+	ref   string
+	data  UpdateRequestCourse
+	files map[string]string
+}
+
+// loadCourseSnapshot fetches the course data and the file list of the course at the given ref
+func (h *Handler) loadCourseSnapshot(ctx context.Context, courseID uint64, ref string) (courseSnapshot, error) {
+	snapshot := courseSnapshot{ref: ref, files: map[string]string{}}
+
+	data, err := h.fetchCourseData(ctx, courseID, ref)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.data = data
+
+	files, err := h.Content.ListFiles(ctx, utils.Uint64ToStr(courseID), ref)
+	if err != nil {
+		return snapshot, err
+	}
+	for _, file := range files {
+		snapshot.files[file.Path] = file.SHA
+	}
+
+	return snapshot, nil
+}
+
+// mergeCourseSnapshots applies the changes made from base to revision on top of current,
+// it returns the merged course data and the file changes to commit on top of current
+func (h *Handler) mergeCourseSnapshots(ctx context.Context, courseID uint64, base, current, revision courseSnapshot) (UpdateRequestCourse, []content.File, []mergeConflict, error) {
+	baseModules, baseModuleOrder, baseSteps, baseStepOrder := indexCourseData(base.data)
+	currentModules, currentModuleOrder, currentSteps, currentStepOrder := indexCourseData(current.data)
+	revisionModules, revisionModuleOrder, revisionSteps, revisionStepOrder := indexCourseData(revision.data)
+
+	conflicts := []mergeConflict{}
+	updateFiles := []content.File{}
+
+	// The description describes the last change, a revision changing it wins over a change merged meanwhile
+	// instead of blocking the rebase
+	description, ok := merge3Value(base.data.Description, current.data.Description, revision.data.Description)
+	if !ok {
+		description = revision.data.Description
+	}
+
+	// Merge the modules
+	keptModules := map[string]CourseModuleRequest{}
+	for _, id := range unionIDs(baseModuleOrder, currentModuleOrder, revisionModuleOrder) {
+		baseModule, inBase := baseModules[id]
+		currentModule, inCurrent := currentModules[id]
+		revisionModule, inRevision := revisionModules[id]
+
+		switch {
+		case !inBase && inRevision:
+			keptModules[id] = revisionModule
+		case !inBase:
+			keptModules[id] = currentModule
+		case !inCurrent && !inRevision:
+			// Removed on both sides
+		case !inCurrent:
+			if revisionModule.Name != baseModule.Name {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictModule, ID: id, Field: "name", Message: "Module was removed from the course but renamed in the revision", Base: baseModule.Name, Revision: revisionModule.Name})
+			}
+		case !inRevision:
+			if currentModule.Name != baseModule.Name {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictModule, ID: id, Field: "name", Message: "Module was renamed in the course but removed in the revision", Base: baseModule.Name, Current: currentModule.Name})
+			}
+		default:
+			name, ok := merge3Value(baseModule.Name, currentModule.Name, revisionModule.Name)
+			if !ok {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictModule, ID: id, Field: "name", Message: "Module was renamed on both sides", Base: baseModule.Name, Current: currentModule.Name, Revision: revisionModule.Name})
+			}
+			currentModule.Name = name
+			keptModules[id] = currentModule
+		}
+	}
+
+	// Merge the steps
+	keptSteps := map[string]stepLocation{}
+	allStepIDs := unionIDs(orderedStepIDs(baseModuleOrder, baseStepOrder), orderedStepIDs(currentModuleOrder, currentStepOrder), orderedStepIDs(revisionModuleOrder, revisionStepOrder))
+	for _, id := range allStepIDs {
+		baseStep, inBase := baseSteps[id]
+		currentStep, inCurrent := currentSteps[id]
+		revisionStep, inRevision := revisionSteps[id]
+
+		currentChanged := inBase && inCurrent && (stepChanged(baseStep, currentStep) || current.files[id] != base.files[id])
+		revisionChanged := inBase && inRevision && (stepChanged(baseStep, revisionStep) || revision.files[id] != base.files[id])
+
+		switch {
+		case !inBase && inRevision:
+			keptSteps[id] = revisionStep
+			if _, ok := revision.files[id]; ok {
+				file, err := h.copyStepFile(ctx, courseID, revision.ref, id, current.files)
+				if err != nil {
+					return UpdateRequestCourse{}, nil, nil, err
+				}
+				updateFiles = append(updateFiles, file)
+			}
+		case !inBase:
+			keptSteps[id] = currentStep
+		case !inCurrent && !inRevision:
+			// Removed on both sides
+		case !inCurrent:
+			if revisionChanged {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictStep, ID: id, Message: "Step was removed from the course but modified in the revision", Base: baseStep.step.Name, Revision: revisionStep.step.Name})
+			}
+		case !inRevision:
+			if currentChanged {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictStep, ID: id, Message: "Step was modified in the course but removed in the revision", Base: baseStep.step.Name, Current: currentStep.step.Name})
+			} else if _, ok := current.files[id]; ok {
+				updateFiles = append(updateFiles, content.File{Path: id, Operation: content.OperationDelete})
+			}
+		default:
+			merged := currentStep
+			name, ok := merge3Value(baseStep.step.Name, currentStep.step.Name, revisionStep.step.Name)
+			if !ok {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictStep, ID: id, Field: "name", Message: "Step was renamed on both sides", Base: baseStep.step.Name, Current: currentStep.step.Name, Revision: revisionStep.step.Name})
+			}
+			merged.step.Name = name
+			stepType, ok := merge3Value(baseStep.step.Type, currentStep.step.Type, revisionStep.step.Type)
+			if !ok {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictStep, ID: id, Field: "type", Message: "Step type was changed on both sides", Base: fmt.Sprint(baseStep.step.Type), Current: fmt.Sprint(currentStep.step.Type), Revision: fmt.Sprint(revisionStep.step.Type)})
+			}
+			merged.step.Type = stepType
+			moduleID, ok := merge3Value(baseStep.moduleID, currentStep.moduleID, revisionStep.moduleID)
+			if !ok {
+				conflicts = append(conflicts, mergeConflict{Kind: conflictStep, ID: id, Field: "module", Message: "Step was moved to different modules on both sides", Base: baseStep.moduleID, Current: currentStep.moduleID, Revision: revisionStep.moduleID})
+			}
+			merged.moduleID = moduleID
+			keptSteps[id] = merged
+
+			file, conflict, err := h.mergeStepFile(ctx, courseID, id, base, current, revision)
+			if err != nil {
+				return UpdateRequestCourse{}, nil, nil, err
+			}
+			if conflict != nil {
+				conflicts = append(conflicts, *conflict)
+			} else if file != nil {
+				updateFiles = append(updateFiles, *file)
+			}
+		}
+	}
+
+	// Every step needs a module to live in
+	for _, id := range allStepIDs {
+		step, ok := keptSteps[id]
+		if !ok {
+			continue
+		}
+		if _, ok := keptModules[step.moduleID]; !ok {
+			conflicts = append(conflicts, mergeConflict{Kind: conflictStep, ID: id, Field: "module", Message: "Step belongs to a module that was removed", Current: step.moduleID})
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return UpdateRequestCourse{}, nil, conflicts, nil
+	}
+
+	// Merge the module and step order
+	moduleOrder, ok := mergeOrder(baseModuleOrder, currentModuleOrder, revisionModuleOrder)
+	if !ok {
+		conflicts = append(conflicts, mergeConflict{Kind: conflictOrder, Message: "Modules were reordered differently on both sides"})
+	}
+	moduleOrder = completeOrder(moduleOrder, unionIDs(currentModuleOrder, revisionModuleOrder), func(id string) bool {
+		_, ok := keptModules[id]
+		return ok
+	})
+
+	mergedData := UpdateRequestCourse{Description: description, Modules: []CourseModuleRequest{}}
+	for i, moduleID := range moduleOrder {
+		stepOrder, ok := mergeOrder(baseStepOrder[moduleID], currentStepOrder[moduleID], revisionStepOrder[moduleID])
+		if !ok {
+			conflicts = append(conflicts, mergeConflict{Kind: conflictOrder, ID: moduleID, Message: "Steps of the module were reordered differently on both sides"})
+		}
+		stepOrder = completeOrder(stepOrder, allStepIDs, func(id string) bool {
+			step, ok := keptSteps[id]
+			return ok && step.moduleID == moduleID
+		})
+
+		module := keptModules[moduleID]
+		module.Position = i + 1
+		module.CourseSteps = make([]CourseStepRequest, 0, len(stepOrder))
+		for j, stepID := range stepOrder {
+			step := keptSteps[stepID].step
+			step.Position = j + 1
+			module.CourseSteps = append(module.CourseSteps, step)
+		}
+		mergedData.Modules = append(mergedData.Modules, module)
+	}
+
+	if len(conflicts) > 0 {
+		return UpdateRequestCourse{}, nil, conflicts, nil
+	}
+	return mergedData, updateFiles, nil, nil
+}
+
+// mergeStepFile merges the content of a step kept on both sides, file is nil when current already has the right content
+func (h *Handler) mergeStepFile(ctx context.Context, courseID uint64, id string, base, current, revision courseSnapshot) (file *content.File, conflict *mergeConflict, err error) {
+	baseSHA, currentSHA, revisionSHA := base.files[id], current.files[id], revision.files[id]
+	if revisionSHA == baseSHA || revisionSHA == currentSHA {
+		return nil, nil, nil
+	}
+	if currentSHA == baseSHA {
+		copied, err := h.copyStepFile(ctx, courseID, revision.ref, id, current.files)
+		return &copied, nil, err
+	}
+
+	// Both sides changed the content
+	baseContent, _, err := h.fetchGitFile(ctx, courseID, base.ref, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	currentContent, _, err := h.fetchGitFile(ctx, courseID, current.ref, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisionContent, _, err := h.fetchGitFile(ctx, courseID, revision.ref, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, contentConflicts := diff.MergeText(baseContent, currentContent, revisionContent)
+	if len(contentConflicts) > 0 {
+		return nil, &mergeConflict{Kind: conflictContent, ID: id, Message: "Step content was changed on both sides", Content: contentConflicts}, nil
+	}
+	return &content.File{Path: id, Content: []byte(merged), Operation: fileOperation(id, current.files)}, nil, nil
+}
+
+// copyStepFile prepares the file of a step as it is at ref to be written on top of current
+func (h *Handler) copyStepFile(ctx context.Context, courseID uint64, ref string, id string, currentFiles map[string]string) (content.File, error) {
+	data, _, err := h.fetchGitFile(ctx, courseID, ref, id)
+	if err != nil {
+		return content.File{}, err
+	}
+	return content.File{Path: id, Content: []byte(data), Operation: fileOperation(id, currentFiles)}, nil
+}
+
+// fileOperation is an update when the file already exists, a create otherwise
+func fileOperation(path string, files map[string]string) content.Operation {
+	if _, ok := files[path]; ok {
+		return content.OperationUpdate
+	}
+	return content.OperationCreate
+}
+
+// stepChanged reports whether the metadata of a step changed
+func stepChanged(old, new stepLocation) bool {
+	return old.step.Name != new.step.Name || old.step.Type != new.step.Type || old.moduleID != new.moduleID
+}
+
+// merge3Value takes the side that changed a value, ok is false when both sides changed it differently
+func merge3Value[T comparable](base, current, revision T) (value T, ok bool) {
+	switch {
+	case current == revision, revision == base:
+		return current, true
+	case current == base:
+		return revision, true
+	default:
+		return current, false
+	}
+}
+
+// mergeOrder merges two reorderings of the same IDs, concurrent insertions at the same place are kept one after the other
+func mergeOrder(base, current, revision []string) ([]string, bool) {
+	// The IDs are unique, the ones removed on either side are dropped first so a removal next to a move doesn't conflict
+	removed := map[string]bool{}
+	for _, id := range base {
+		removed[id] = !containsID(current, id) || !containsID(revision, id)
+	}
+	keep := func(id string) bool { return !removed[id] }
+	base, current, revision = filterIDs(base, keep), filterIDs(current, keep), filterIDs(revision, keep)
+
+	merged, conflicts := diff.Merge3(base, current, revision)
+
+	for i := len(conflicts) - 1; i >= 0; i-- {
+		conflict := conflicts[i]
+		if len(conflict.Base) > 0 {
+			return merged, false
+		}
+		at := conflict.Position + len(conflict.Ours)
+		merged = append(merged[:at], append(append([]string{}, conflict.Theirs...), merged[at:]...)...)
+	}
+	return merged, true
+}
+
+// completeOrder drops the IDs not kept or duplicated from order and appends the kept IDs it is missing
+func completeOrder(order []string, all []string, keep func(id string) bool) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, ids := range [][]string{order, all} {
+		for _, id := range ids {
+			if seen[id] || !keep(id) {
+				continue
+			}
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// containsID reports whether the ID is in ids
+func containsID(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// filterIDs lists the IDs to keep in their order
+func filterIDs(ids []string, keep func(id string) bool) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if keep(id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// orderedStepIDs lists the step IDs module by module
+func orderedStepIDs(moduleOrder []string, stepOrder map[string][]string) []string {
+	ids := []string{}
+	for _, moduleID := range moduleOrder {
+		ids = append(ids, stepOrder[moduleID]...)
+	}
+	return ids
+}
+
+// unionIDs lists every ID once, in the order they are first seen
+func unionIDs(orders ...[]string) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, order := range orders {
+		for _, id := range order {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,324 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/pkg/content"
+)
+
+const testCourseID = 1
+
+// courseVersion is the course data and the step files of one side of a merge
+type courseVersion struct {
+	data  UpdateRequestCourse
+	files map[string]string
+}
+
+func testCourse(description string, modules ...CourseModuleRequest) UpdateRequestCourse {
+	for i := range modules {
+		modules[i].Position = i + 1
+	}
+	return UpdateRequestCourse{Description: description, Modules: modules}
+}
+
+func testModule(id, name string, steps ...CourseStepRequest) CourseModuleRequest {
+	for i := range steps {
+		steps[i].Position = i + 1
+	}
+	return CourseModuleRequest{ID: &id, Name: name, CourseSteps: append([]CourseStepRequest{}, steps...)}
+}
+
+func testStep(id, name string) CourseStepRequest {
+	return CourseStepRequest{ID: &id, Name: name}
+}
+
+// commitVersion commits the files and the course data of version on top of parentFiles
+func commitVersion(t *testing.T, store content.ContentStore, request content.CommitRequest, parentFiles map[string]string, version courseVersion) content.Commit {
+	t.Helper()
+
+	courseData, err := json.Marshal(version.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Files = []content.File{{Path: models.CourseDataFile, Content: courseData, Operation: fileOperation(models.CourseDataFile, parentFiles)}}
+	for path, text := range version.files {
+		request.Files = append(request.Files, content.File{Path: path, Content: []byte(text), Operation: fileOperation(path, parentFiles)})
+	}
+	for path := range parentFiles {
+		if _, ok := version.files[path]; !ok && path != models.CourseDataFile {
+			request.Files = append(request.Files, content.File{Path: path, Operation: content.OperationDelete})
+		}
+	}
+	request.Message = "test"
+
+	commit, err := store.CommitFiles(context.Background(), fmt.Sprint(testCourseID), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+// loadTestSnapshots commits the three versions in a local store, the revision branch starts from base
+// and current is committed on the default branch after it
+func loadTestSnapshots(t *testing.T, base, current, revision courseVersion) (*Handler, courseSnapshot, courseSnapshot, courseSnapshot) {
+	t.Helper()
+	ctx := context.Background()
+
+	store, err := content.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateRepo(ctx, fmt.Sprint(testCourseID), defaultBranch); err != nil {
+		t.Fatal(err)
+	}
+
+	baseCommit := commitVersion(t, store, content.CommitRequest{Branch: defaultBranch}, map[string]string{}, base)
+	baseFiles := map[string]string{models.CourseDataFile: ""}
+	for path, text := range base.files {
+		baseFiles[path] = text
+	}
+	commitVersion(t, store, content.CommitRequest{Branch: defaultBranch, NewBranch: "revision"}, baseFiles, revision)
+	commitVersion(t, store, content.CommitRequest{Branch: defaultBranch}, baseFiles, current)
+
+	h := NewHandler(&app.App{Content: store})
+	snapshots := []courseSnapshot{}
+	for _, ref := range []string{baseCommit.SHA, defaultBranch, "revision"} {
+		snapshot, err := h.loadCourseSnapshot(ctx, testCourseID, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return h, snapshots[0], snapshots[1], snapshots[2]
+}
+
+// numberedLines returns a text of count lines made of the prefix and their index
+func numberedLines(prefix string, count int) string {
+	lines := make([]string, count)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestMergeCourseSnapshots(t *testing.T) {
+	base := courseVersion{
+		data: testCourse("Base",
+			testModule("10", "Intro", testStep("11", "Welcome"), testStep("12", "Setup")),
+			testModule("20", "Basics", testStep("21", "Variables")),
+		),
+		files: map[string]string{"11": "hello\n", "12": "install\nrun\n", "21": "x = 1\n"},
+	}
+	// Rewriting every fourth line is 400 changed lines in the short file and 600 in the long one,
+	// an edit distance of 800 and 1200
+	shortFile := numberedLines("line", 1600)
+	longFile := numberedLines("line", 2400)
+
+	tests := []struct {
+		name string
+		// base replaces the shared base when set
+		base              *courseVersion
+		current, revision courseVersion
+		want              UpdateRequestCourse
+		// files maps the path of every file change to its content, or to "" for a deletion
+		files     map[string]string
+		conflicts []conflictKind
+	}{
+		{
+			name:    "clean merge",
+			current: courseVersion{data: testCourse("Rename intro", testModule("10", "Getting started", testStep("11", "Welcome"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))), files: base.files},
+			revision: courseVersion{
+				data:  testCourse("Rename welcome", testModule("10", "Intro", testStep("11", "Hello"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))),
+				files: map[string]string{"11": "hello\n", "12": "install\nrun\ntest\n", "21": "x = 1\n"},
+			},
+			want:  testCourse("Rename welcome", testModule("10", "Getting started", testStep("11", "Hello"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))),
+			files: map[string]string{"12": "install\nrun\ntest\n"},
+		},
+		{
+			name:     "content edited on both sides",
+			current:  courseVersion{data: base.data, files: map[string]string{"11": "hello\n", "12": "sudo install\nrun\n", "21": "x = 1\n"}},
+			revision: courseVersion{data: testCourse("Edit setup", base.data.Modules...), files: map[string]string{"11": "hello\n", "12": "install\nrun\ntest\n", "21": "x = 1\n"}},
+			want:     testCourse("Edit setup", base.data.Modules...),
+			files:    map[string]string{"12": "sudo install\nrun\ntest\n"},
+		},
+		{
+			name:      "conflicting renames",
+			current:   courseVersion{data: testCourse("Base", testModule("10", "Intro", testStep("11", "Hi"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))), files: base.files},
+			revision:  courseVersion{data: testCourse("Base", testModule("10", "Intro", testStep("11", "Hello"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))), files: base.files},
+			conflicts: []conflictKind{conflictStep},
+		},
+		{
+			name:      "conflicting content",
+			current:   courseVersion{data: base.data, files: map[string]string{"11": "hi\n", "12": "install\nrun\n", "21": "x = 1\n"}},
+			revision:  courseVersion{data: base.data, files: map[string]string{"11": "hey\n", "12": "install\nrun\n", "21": "x = 1\n"}},
+			conflicts: []conflictKind{conflictContent},
+		},
+		{
+			name:      "removed in the course but modified in the revision",
+			current:   courseVersion{data: testCourse("Base", testModule("10", "Intro", testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))), files: map[string]string{"12": "install\nrun\n", "21": "x = 1\n"}},
+			revision:  courseVersion{data: testCourse("Base", testModule("10", "Intro", testStep("11", "Hello"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"))), files: base.files},
+			conflicts: []conflictKind{conflictStep},
+		},
+		{
+			name:     "reorder and delete",
+			current:  courseVersion{data: testCourse("Base", testModule("20", "Basics", testStep("21", "Variables")), testModule("10", "Intro", testStep("12", "Setup"), testStep("11", "Welcome"))), files: base.files},
+			revision: courseVersion{data: testCourse("Remove setup", testModule("10", "Intro", testStep("11", "Welcome")), testModule("20", "Basics", testStep("21", "Variables"))), files: map[string]string{"11": "hello\n", "21": "x = 1\n"}},
+			want:     testCourse("Remove setup", testModule("20", "Basics", testStep("21", "Variables")), testModule("10", "Intro", testStep("11", "Welcome"))),
+			files:    map[string]string{"12": ""},
+		},
+		{
+			name:     "additions on both sides",
+			current:  courseVersion{data: testCourse("Add advanced", append(base.data.Modules, testModule("30", "Advanced", testStep("31", "Generics")))...), files: map[string]string{"11": "hello\n", "12": "install\nrun\n", "21": "x = 1\n", "31": "T any\n"}},
+			revision: courseVersion{data: testCourse("Add loops", testModule("10", "Intro", testStep("11", "Welcome"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"), testStep("22", "Loops"))), files: map[string]string{"11": "hello\n", "12": "install\nrun\n", "21": "x = 1\n", "22": "for {}\n"}},
+			want:     testCourse("Add loops", testModule("10", "Intro", testStep("11", "Welcome"), testStep("12", "Setup")), testModule("20", "Basics", testStep("21", "Variables"), testStep("22", "Loops")), testModule("30", "Advanced", testStep("31", "Generics"))),
+			files:    map[string]string{"22": "for {}\n"},
+		},
+		{
+			name:     "modules added at the same place keep both",
+			current:  courseVersion{data: testCourse("Base", testModule("10", "Intro", testStep("11", "Welcome"), testStep("12", "Setup")), testModule("30", "Tools"), testModule("20", "Basics", testStep("21", "Variables"))), files: base.files},
+			revision: courseVersion{data: testCourse("Base", testModule("10", "Intro", testStep("11", "Welcome"), testStep("12", "Setup")), testModule("40", "History"), testModule("20", "Basics", testStep("21", "Variables"))), files: base.files},
+			want:     testCourse("Base", testModule("10", "Intro", testStep("11", "Welcome"), testStep("12", "Setup")), testModule("30", "Tools"), testModule("40", "History"), testModule("20", "Basics", testStep("21", "Variables"))),
+			files:    map[string]string{},
+		},
+		{
+			name:     "content rewritten under the edit distance",
+			base:     &courseVersion{data: base.data, files: map[string]string{"11": shortFile, "12": "install\nrun\n", "21": "x = 1\n"}},
+			current:  courseVersion{data: base.data, files: map[string]string{"11": editLine(shortFile, 1002), "12": "install\nrun\n", "21": "x = 1\n"}},
+			revision: courseVersion{data: base.data, files: map[string]string{"11": rewriteLines(shortFile, 4), "12": "install\nrun\n", "21": "x = 1\n"}},
+			want:     base.data,
+			files:    map[string]string{"11": editLine(rewriteLines(shortFile, 4), 1002)},
+		},
+		{
+			// The rewrite is above the edit distance the diff computes, so the unchanged lines aren't matched
+			// and the edit made in the course can't be placed anymore
+			name:      "content rewritten past the edit distance",
+			base:      &courseVersion{data: base.data, files: map[string]string{"11": longFile, "12": "install\nrun\n", "21": "x = 1\n"}},
+			current:   courseVersion{data: base.data, files: map[string]string{"11": editLine(longFile, 1002), "12": "install\nrun\n", "21": "x = 1\n"}},
+			revision:  courseVersion{data: base.data, files: map[string]string{"11": rewriteLines(longFile, 4), "12": "install\nrun\n", "21": "x = 1\n"}},
+			conflicts: []conflictKind{conflictContent},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testBase := base
+			if test.base != nil {
+				testBase = *test.base
+			}
+			h, baseSnapshot, currentSnapshot, revisionSnapshot := loadTestSnapshots(t, testBase, test.current, test.revision)
+
+			merged, files, conflicts, err := h.mergeCourseSnapshots(context.Background(), testCourseID, baseSnapshot, currentSnapshot, revisionSnapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conflictKinds := []conflictKind{}
+			for _, conflict := range conflicts {
+				conflictKinds = append(conflictKinds, conflict.Kind)
+			}
+			if len(test.conflicts) > 0 || len(conflictKinds) > 0 {
+				if !reflect.DeepEqual(conflictKinds, test.conflicts) {
+					t.Fatalf("conflicts = %+v, want kinds %v", conflicts, test.conflicts)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(merged, test.want) {
+				gotJSON, _ := json.Marshal(merged)
+				wantJSON, _ := json.Marshal(test.want)
+				t.Errorf("merged = %s, want %s", gotJSON, wantJSON)
+			}
+
+			gotFiles := map[string]string{}
+			for _, file := range files {
+				gotFiles[file.Path] = string(file.Content)
+			}
+			if !reflect.DeepEqual(gotFiles, test.files) {
+				t.Errorf("file changes = %q, want %q", gotFiles, test.files)
+			}
+		})
+	}
+}
+
+// rewriteLines changes one line out of every
+func rewriteLines(text string, every int) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := 0; i < len(lines); i += every {
+		lines[i] = strings.ToUpper(lines[i]) + "!"
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// editLine changes a single line of the text
+func editLine(text string, index int) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	lines[index] = "edited " + lines[index]
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestMerge3Value(t *testing.T) {
+	tests := []struct {
+		name                    string
+		base, current, revision string
+		want                    string
+		ok                      bool
+	}{
+		{name: "unchanged", base: "a", current: "a", revision: "a", want: "a", ok: true},
+		{name: "changed in the course", base: "a", current: "b", revision: "a", want: "b", ok: true},
+		{name: "changed in the revision", base: "a", current: "a", revision: "c", want: "c", ok: true},
+		{name: "same change", base: "a", current: "b", revision: "b", want: "b", ok: true},
+		{name: "different changes", base: "a", current: "b", revision: "c", want: "b", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := merge3Value(test.base, test.current, test.revision)
+			if got != test.want || ok != test.ok {
+				t.Errorf("merge3Value(%q, %q, %q) = %q, %v, want %q, %v", test.base, test.current, test.revision, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestMergeOrder(t *testing.T) {
+	tests := []struct {
+		name                    string
+		base, current, revision []string
+		want                    []string
+		ok                      bool
+	}{
+		{name: "unchanged", base: []string{"a", "b"}, current: []string{"a", "b"}, revision: []string{"a", "b"}, want: []string{"a", "b"}, ok: true},
+		{name: "reordered on one side", base: []string{"a", "b", "c"}, current: []string{"a", "b", "c"}, revision: []string{"c", "a", "b"}, want: []string{"c", "a", "b"}, ok: true},
+		{name: "reorder and delete", base: []string{"a", "b", "c"}, current: []string{"b", "a", "c"}, revision: []string{"a", "b"}, want: []string{"b", "a"}, ok: true},
+		{name: "insertions at the same place", base: []string{"a", "b"}, current: []string{"a", "x", "b"}, revision: []string{"a", "y", "b"}, want: []string{"a", "x", "y", "b"}, ok: true},
+		{name: "reordered differently", base: []string{"a", "b", "c"}, current: []string{"b", "a", "c"}, revision: []string{"a", "c", "b"}, ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := mergeOrder(test.base, test.current, test.revision)
+			if ok != test.ok {
+				t.Fatalf("mergeOrder() ok = %v, want %v", ok, test.ok)
+			}
+			if ok && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("mergeOrder() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompleteOrder(t *testing.T) {
+	kept := map[string]bool{"a": true, "b": true, "c": true}
+	got := completeOrder([]string{"b", "x", "b", "a"}, []string{"a", "b", "c", "y"}, func(id string) bool { return kept[id] })
+	want := []string{"b", "a", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("completeOrder() = %q, want %q", got, want)
+	}
+}
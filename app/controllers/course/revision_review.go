@@ -0,0 +1,160 @@
+package courses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/app/workers"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
+)
+
+// createReviewRequest is the type for the request body of reviewing a revision.
+type createReviewRequest struct {
+	Verdict string `json:"verdict" binding:"required"`
+	Body    string `json:"body" binding:"required_unless=Verdict approve,max=5000"`
+}
+
+// revisionReviewResponse is a review with the public data of its reviewer
+type revisionReviewResponse struct {
+	models.RevisionReview
+	Reviewer *models.UserPublicProfile `json:"reviewer,omitempty"`
+}
+
+// ListRevisionReviews handles listing the reviews of a revision
+func (h *Handler) ListRevisionReviews(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok {
+		return
+	}
+
+	reviews, result := queries.GetRevisionReviews(h.DB.WithContext(c), revision.ID)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching reviews", utils.ErrGetData, result.Error)
+		return
+	}
+
+	response := make([]revisionReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		item := revisionReviewResponse{}
+		if review.Reviewer != nil {
+			item.Reviewer = &models.UserPublicProfile{}
+			if err := copier.Copy(item.Reviewer, review.Reviewer); err != nil {
+				utils.ServerErrorResponse(c, 500, "Error processing reviews", utils.ErrChangeType, err)
+				return
+			}
+		}
+		review.Reviewer = nil
+		item.RevisionReview = review
+		response = append(response, item)
+	}
+
+	utils.FullyResponse(c, 200, "Successfully get reviews", nil, response)
+}
+
+// CreateRevisionReview handles approving, requesting changes on or commenting on a revision
+func (h *Handler) CreateRevisionReview(c *gin.Context) {
+	var request createReviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	verdict, ok := models.ParseStringToReviewVerdict(request.Verdict)
+	if !ok {
+		utils.FullyResponse(c, 400, "Invalid review verdict", utils.ErrBadRequest, nil)
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok {
+		return
+	}
+
+	if revision.Status != models.RevisionOpen {
+		utils.FullyResponse(c, 400, "Only open revisions can be reviewed", utils.ErrRevisionNotOpen, nil)
+		return
+	}
+
+	if revision.EditorID == userID && verdict != models.ReviewComment {
+		utils.FullyResponse(c, 403, "You can't review your own revision", utils.ErrPermissionDenied, nil)
+		return
+	}
+
+	review := models.RevisionReview{
+		ID:         encryption.GenerateID(),
+		RevisionID: revision.ID,
+		ReviewerID: userID,
+		Verdict:    verdict,
+		Body:       request.Body,
+		CreatedAt:  time.Now(),
+	}
+
+	// The review is mirrored on the pull request by the outbox worker
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := queries.CreateRevisionReview(tx, review)
+		if result.Error != nil {
+			return result.Error
+		}
+		return enqueueOutboxEvent(tx, models.OutboxMirrorReview, utils.Uint64ToStr(review.ID), models.ReviewMirrorPayload{
+			RevisionID: revision.ID,
+			ReviewID:   review.ID,
+		})
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error saving review", utils.ErrSaveData, err)
+		return
+	}
+	workers.NotifyOutbox()
+
+	utils.FullyResponse(c, 201, "Successfully created review", nil, review)
+}
+
+var reviewStates = map[models.ReviewVerdict]content.ReviewState{
+	models.ReviewComment:        content.ReviewComment,
+	models.ReviewApprove:        content.ReviewApprove,
+	models.ReviewRequestChanges: content.ReviewRequestChanges,
+}
+
+// mirrorReviewToGitea copies the review on the pull request of the revision
+func (h *Handler) mirrorReviewToGitea(ctx context.Context, revision models.CourseRevision, review models.RevisionReview) error {
+	reviewer, result := queries.GetUserQueueByID(h.DB.WithContext(ctx), review.ReviewerID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	body := fmt.Sprintf("**@%s** reviewed: %s", reviewer.Username, review.Verdict.String())
+	if review.Body != "" {
+		body += "\n\n" + review.Body
+	}
+
+	// A retry after the review was posted but not recorded finds it by its marker instead of posting it twice
+	repoName := utils.Uint64ToStr(revision.CourseID)
+	marker := fmt.Sprintf("<!-- revision-review:%s -->", utils.Uint64ToStr(review.ID))
+	giteaReviewID, err := h.Content.FindReview(ctx, repoName, int64(revision.PullRequestID), marker)
+	if errors.Is(err, content.ErrNotFound) {
+		giteaReviewID, err = h.Content.Review(ctx, repoName, int64(revision.PullRequestID), reviewStates[review.Verdict], body+"\n\n"+marker)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mirror review on pull request: %w", err)
+	}
+
+	result = queries.UpdateRevisionReviewGiteaID(h.DB.WithContext(ctx), review.ID, giteaReviewID)
+	return result.Error
+}
@@ -0,0 +1,182 @@
+package courses
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// closeRevisionRequest is the type for the request body of closing a revision.
+type closeRevisionRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// CloseRevision handles closing (rejecting) a revision, the editor can also withdraw their own revision unless it is locked
+func (h *Handler) CloseRevision(c *gin.Context) {
+	var request closeRevisionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	revision, userID, ok := h.getRevisionForStatusChange(c, models.RevisionClose, true)
+	if !ok {
+		return
+	}
+
+	original := revision
+	now := time.Now()
+	revision.Status = models.RevisionClose
+	revision.CloseReason = request.Reason
+	revision.ClosedByID = &userID
+	revision.ClosedAt = &now
+	revision.StatusBeforeLock = nil
+	revision.UpdatedAt = now
+	if !h.saveRevisionStatus(c, revision, original.Status) {
+		return
+	}
+
+	// The status is written first so a concurrent approval can't be undone, it is put back when Gitea fails
+	if err := h.setPullRequestState(c, revision, content.ChangeRequestClosed); err != nil {
+		queries.UpdateCourseRevisionState(h.DB.WithContext(c), original, revision.Status)
+		utils.ServerErrorResponse(c, 500, "Error closing pull request", utils.ErrSaveCourseFile, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Revision successfully closed", nil, revision)
+}
+
+// ReopenRevision handles reopening a closed or locked revision while its branch still exists,
+// the editor can only reopen a revision they closed themselves
+func (h *Handler) ReopenRevision(c *gin.Context) {
+	revision, _, ok := h.getRevisionForStatusChange(c, models.RevisionOpen, true)
+	if !ok {
+		return
+	}
+
+	if revision.BranchDeleted {
+		utils.FullyResponse(c, 410, "Revision branch was already deleted", utils.ErrRevisionBranchDeleted, nil)
+		return
+	}
+
+	original := revision
+	revision.Status = models.RevisionOpen
+	revision.CloseReason = ""
+	revision.ClosedByID = nil
+	revision.ClosedAt = nil
+	revision.StatusBeforeLock = nil
+	revision.UpdatedAt = time.Now()
+	if !h.saveRevisionStatus(c, revision, original.Status) {
+		return
+	}
+
+	if err := h.setPullRequestState(c, revision, content.ChangeRequestOpen); err != nil {
+		queries.UpdateCourseRevisionState(h.DB.WithContext(c), original, revision.Status)
+		utils.ServerErrorResponse(c, 500, "Error reopening pull request", utils.ErrSaveCourseFile, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Revision successfully reopened", nil, revision)
+}
+
+// LockRevision handles locking a revision so no one can comment or update it
+func (h *Handler) LockRevision(c *gin.Context) {
+	revision, _, ok := h.getRevisionForStatusChange(c, models.RevisionLock, false)
+	if !ok {
+		return
+	}
+
+	previousStatus := revision.Status
+	revision.StatusBeforeLock = &previousStatus
+	revision.Status = models.RevisionLock
+	revision.UpdatedAt = time.Now()
+	if !h.saveRevisionStatus(c, revision, previousStatus) {
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Revision successfully locked", nil, revision)
+}
+
+// UnlockRevision handles unlocking a revision back to the status it had before being locked
+func (h *Handler) UnlockRevision(c *gin.Context) {
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok {
+		return
+	}
+
+	if revision.Status != models.RevisionLock {
+		utils.FullyResponse(c, 400, "Revision is not locked", utils.ErrInvalidRevisionStatus, nil)
+		return
+	}
+
+	revision.Status = models.RevisionOpen
+	if revision.StatusBeforeLock != nil {
+		revision.Status = *revision.StatusBeforeLock
+	}
+	revision.StatusBeforeLock = nil
+	revision.UpdatedAt = time.Now()
+	if !h.saveRevisionStatus(c, revision, models.RevisionLock) {
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Revision successfully unlocked", nil, revision)
+}
+
+// getRevisionForStatusChange fetches the revision from the URL and checks the user can move it to the next status,
+// the response is already written when ok is false
+func (h *Handler) getRevisionForStatusChange(c *gin.Context, next models.RevisionStatus, allowEditor bool) (revision models.CourseRevision, userID uint64, ok bool) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return revision, 0, false
+	}
+
+	course := c.MustGet("course").(models.Course)
+	revision, ok = h.getCourseRevision(c, course.ID)
+	if !ok || !ensureRevisionReady(c, revision) {
+		return revision, 0, false
+	}
+
+	// Maintainers can manage every revision, editors only their own, and they can't undo a lock
+	// or reopen a revision someone else closed
+	role, isMember := utils.GetCourseRoleFromContext(c)
+	isMaintainer := isMember && role >= models.CourseMaintainer
+	closedByOther := revision.Status == models.RevisionClose && revision.ClosedByID != nil && *revision.ClosedByID != userID
+	if !isMaintainer && (!allowEditor || revision.EditorID != userID || revision.Status == models.RevisionLock || closedByOther) {
+		utils.FullyResponse(c, 403, "You don't have permission to do this", utils.ErrPermissionDenied, nil)
+		return revision, 0, false
+	}
+
+	if !revision.Status.CanTransitionTo(next) {
+		utils.FullyResponse(c, 400, fmt.Sprintf("A %s revision can't be %s", revision.Status, next), utils.ErrInvalidRevisionStatus, nil)
+		return revision, 0, false
+	}
+
+	return revision, userID, true
+}
+
+// saveRevisionStatus writes the status change of the revision unless its status moved away from the one it was read with,
+// the response is already written when ok is false
+func (h *Handler) saveRevisionStatus(c *gin.Context, revision models.CourseRevision, from models.RevisionStatus) (ok bool) {
+	result := queries.UpdateCourseRevisionState(h.DB.WithContext(c), revision, from)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating revision", utils.ErrSaveData, result.Error)
+		return false
+	} else if result.RowsAffected == 0 {
+		utils.FullyResponse(c, 409, "Revision status changed meanwhile, try again", utils.ErrInvalidRevisionStatus, nil)
+		return false
+	}
+	return true
+}
+
+// setPullRequestState opens or closes the pull request of the revision
+func (h *Handler) setPullRequestState(ctx context.Context, revision models.CourseRevision, state content.ChangeRequestState) error {
+	return h.Content.SetChangeRequestState(ctx, utils.Uint64ToStr(revision.CourseID), int64(revision.PullRequestID), state)
+}
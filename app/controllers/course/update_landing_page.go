@@ -22,14 +22,14 @@ type courseLandingPageRequest struct {
 }
 
 // UpdateCourseLandingPage handles updating course landing page details
-func UpdateCourseLandingPage(c *gin.Context) {
+func (h *Handler) UpdateCourseLandingPage(c *gin.Context) {
 	courseID, err := utils.StrToUint64(c.Param("courseID"))
 	if err != nil {
 		utils.FullyResponse(c, http.StatusBadRequest, "Invalid course ID", utils.ErrBadRequest, nil)
 		return
 	}
 
-	if _, result := queries.GetCourseWithDetails(courseID); result.Error != nil {
+	if _, result := queries.GetCourseWithDetails(h.DB.WithContext(c), courseID); result.Error != nil {
 		if result.RowsAffected == 0 {
 			utils.FullyResponse(c, http.StatusNotFound, "Course not found", utils.ErrCourseNotExist, nil)
 		} else {
@@ -45,15 +45,15 @@ func UpdateCourseLandingPage(c *gin.Context) {
 	}
 
 	landingPage := models.CourseLandingPage{CourseID: courseID}
-	if _, result := queries.GetCourseLandingPage(landingPage.CourseID); result.Error != nil {
-		createLandingPage(c, courseID, request)
+	if _, result := queries.GetCourseLandingPage(h.DB.WithContext(c), landingPage.CourseID); result.Error != nil {
+		h.createLandingPage(c, courseID, request)
 		return
 	}
 
-	updateLandingPage(c, landingPage, request)
+	h.updateLandingPage(c, landingPage, request)
 }
 
-func createLandingPage(c *gin.Context, courseID uint64, request courseLandingPageRequest) {
+func (h *Handler) createLandingPage(c *gin.Context, courseID uint64, request courseLandingPageRequest) {
 	landingPage := models.CourseLandingPage{
 		CourseID:       courseID,
 		Description:    request.Description,
@@ -67,14 +67,14 @@ func createLandingPage(c *gin.Context, courseID uint64, request courseLandingPag
 		UpdatedAt:      time.Now(),
 	}
 
-	if err := queries.CreateCourseLandingPage(landingPage).Error; err != nil {
+	if err := queries.CreateCourseLandingPage(h.DB.WithContext(c), landingPage).Error; err != nil {
 		utils.ServerErrorResponse(c, http.StatusInternalServerError, "Error creating landing page", utils.ErrSaveData, err)
 		return
 	}
 	utils.FullyResponse(c, http.StatusCreated, "Landing page created successfully", nil, landingPage)
 }
 
-func updateLandingPage(c *gin.Context, landingPage models.CourseLandingPage, request courseLandingPageRequest) {
+func (h *Handler) updateLandingPage(c *gin.Context, landingPage models.CourseLandingPage, request courseLandingPageRequest) {
 	landingPage.Description = request.Description
 	landingPage.ImageURL = request.ImageURL
 	landingPage.VideoURL = request.VideoURL
@@ -84,7 +84,7 @@ func updateLandingPage(c *gin.Context, landingPage models.CourseLandingPage, req
 	landingPage.TargetAudience = request.TargetAudience
 	landingPage.UpdatedAt = time.Now()
 
-	if err := queries.UpdateCourseLandingPage(landingPage).Error; err != nil {
+	if err := queries.UpdateCourseLandingPage(h.DB.WithContext(c), landingPage).Error; err != nil {
 		utils.ServerErrorResponse(c, http.StatusInternalServerError, "Error updating landing page", utils.ErrSaveData, err)
 		return
 	}
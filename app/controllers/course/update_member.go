@@ -0,0 +1,95 @@
+package courses
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// updateMemberRequest is the type for the request body of changing a member role.
+type updateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UpdateCourseMemberRole handles changing the role of a course member
+func (h *Handler) UpdateCourseMemberRole(c *gin.Context) {
+	var request updateMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	role, ok := models.ParseStringToCourseRole(request.Role)
+	if !ok {
+		utils.FullyResponse(c, 400, "Invalid role", utils.ErrInvalidCourseRole, nil)
+		return
+	}
+
+	course := c.MustGet("course").(models.Course)
+	member, ok := h.getTargetMember(c, course)
+	if !ok {
+		return
+	}
+
+	// Maintainers can only manage roles below their own, owners can manage everyone
+	actorRole, _ := utils.GetCourseRoleFromContext(c)
+	if actorRole != models.CourseOwner && (member.Role >= actorRole || role >= actorRole) {
+		utils.FullyResponse(c, 403, "You don't have permission to do this", utils.ErrPermissionDenied, nil)
+		return
+	}
+
+	lastOwner := false
+	err := h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if role != models.CourseOwner {
+			last, err := isLastOwner(tx, course.ID, member.UserID)
+			if err != nil || last {
+				lastOwner = last
+				return err
+			}
+		}
+		return queries.UpdateCourseMemberRole(tx, course.ID, member.UserID, role).Error
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating course member", utils.ErrSaveData, err)
+		return
+	}
+	if lastOwner {
+		utils.FullyResponse(c, 400, "A course needs at least one owner", utils.ErrLastCourseOwner, nil)
+		return
+	}
+
+	member.Role = role
+	utils.FullyResponse(c, 200, "Successfully updated course member", nil, member)
+}
+
+// getTargetMember fetches the member from the URL, the response is already written when ok is false
+func (h *Handler) getTargetMember(c *gin.Context, course models.Course) (member models.CourseMember, ok bool) {
+	userID, err := utils.StrToUint64(c.Param("userID"))
+	if err != nil {
+		utils.FullyResponse(c, 400, "Invalid user ID", utils.ErrBadRequest, nil)
+		return member, false
+	}
+
+	member, result := queries.GetCourseMember(h.DB.WithContext(c), course.ID, userID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 404, "Course member not found", utils.ErrNotCourseMember, nil)
+		return member, false
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching course member", utils.ErrGetData, result.Error)
+		return member, false
+	}
+
+	return member, true
+}
+
+// isLastOwner reports whether the user is the only owner of the course, the owner rows stay locked
+// until tx ends so a concurrent change can't remove the other owners meanwhile
+func isLastOwner(tx *gorm.DB, courseID uint64, userID uint64) (bool, error) {
+	owners, result := queries.LockCourseMembersByRole(tx, courseID, models.CourseOwner)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return len(owners) == 1 && owners[0] == userID, nil
+}
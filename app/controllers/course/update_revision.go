@@ -0,0 +1,116 @@
+package courses
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// UpdateRevision handles pushing additional changes to the branch of an open revision
+func (h *Handler) UpdateRevision(c *gin.Context) {
+	var request UpdateRequestCourse
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	course := c.MustGet("course").(models.Course)
+	revision, ok := h.getCourseRevision(c, course.ID)
+	if !ok || !ensureRevisionReady(c, revision) {
+		return
+	}
+
+	// Only the editor and maintainers can push to a revision
+	role, isMember := utils.GetCourseRoleFromContext(c)
+	if revision.EditorID != userID && !(isMember && role >= models.CourseMaintainer) {
+		utils.FullyResponse(c, 403, "You don't have permission to update this revision", utils.ErrPermissionDenied, nil)
+		return
+	}
+
+	if revision.Status == models.RevisionLock {
+		utils.FullyResponse(c, 403, "Revision is locked", utils.ErrRevisionLocked, nil)
+		return
+	} else if revision.Status != models.RevisionOpen {
+		utils.FullyResponse(c, 400, "Only open revisions can be updated", utils.ErrRevisionNotOpen, nil)
+		return
+	}
+
+	// Sort modules and steps by position
+	sortModulesAndSteps(&request)
+
+	// Validate module and step positions
+	if err := validatePositions(request.Modules); err != nil {
+		utils.FullyResponse(c, 400, err.Error(), utils.ErrBadRequest, nil)
+		return
+	}
+
+	// The steps of the revision are the ones on its branch
+	branch := utils.Uint64ToStr(revision.BranchID)
+	branchCourseData, err := h.fetchCourseData(c, course.ID, branch)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching revision data", utils.ErrGetData, err)
+		return
+	}
+	_, _, branchSteps, _ := indexCourseData(branchCourseData)
+	existingStepIDs := make(map[string]bool, len(branchSteps))
+	for stepID := range branchSteps {
+		existingStepIDs[stepID] = true
+	}
+
+	// Process updates for course steps
+	request, updateFiles, err := processCourseSteps(request, existingStepIDs)
+	if err != nil {
+		utils.FullyResponse(c, 400, "Error processing course steps", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	// Prepare and encode course data
+	courseDataJson, err := encodeCourseData(request)
+	if err != nil {
+		utils.FullyResponse(c, 500, "Error encoding course data", utils.ErrParseData, nil)
+		return
+	}
+
+	// The revision row stays locked while pushing, an approval or a rebase waits for the push to be recorded
+	// and a revision that stopped being open on this branch meanwhile is left alone
+	var revisionCommit models.RevisionCommit
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := queries.TouchOpenRevision(tx, revision.ID, revision.BranchID)
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Commit on the existing branch, the pull request picks it up by itself
+		commit, err := h.commitCourseChanges(c, course.ID, updateFiles, request.Description, courseDataJson, userID, content.CommitRequest{
+			Branch: branch,
+		})
+		if err != nil {
+			return err
+		}
+
+		revisionCommit = newRevisionCommit(revision.ID, userID, commit)
+		return queries.CreateRevisionCommit(tx, revisionCommit).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 409, "Revision changed meanwhile, it is no longer open on this branch", utils.ErrRevisionNotOpen, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating course in git", utils.ErrSaveCourseFile, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Revision successfully updated", nil, revisionCommit)
+}
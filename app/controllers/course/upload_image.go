@@ -8,18 +8,17 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/app/queries"
 	"github.com/instructhub/backend/pkg/encryption"
-	store "github.com/instructhub/backend/pkg/s3"
+	"github.com/instructhub/backend/pkg/metrics"
 	"github.com/instructhub/backend/pkg/utils"
 	"gorm.io/gorm"
 )
 
-// UploadImage handles the image upload to S3 and saving the image metadata in the database
-func UploadImage(c *gin.Context) {
+// UploadImage handles the image upload to the blob store and saving the image metadata in the database
+func (h *Handler) UploadImage(c *gin.Context) {
 	// Parse image file and course ID from request
 	file, err := c.FormFile("image")
 	if err != nil {
@@ -40,7 +39,7 @@ func UploadImage(c *gin.Context) {
 	}
 
 	// Validate course existence
-	if err := validateCourseExistence(courseID); err != nil {
+	if err := h.validateCourseExistence(c, courseID); err != nil {
 		utils.ServerErrorResponse(c, 400, "This course doesn't exist", utils.ErrCourseNotExist, err)
 		return
 	}
@@ -81,26 +80,28 @@ func UploadImage(c *gin.Context) {
 		return
 	}
 
-	// Generate S3 file path and upload the image
+	// Generate the file path and upload the image
 	filePath, imageID, err := generateFilePath(courseID, file.Filename)
 	if err != nil {
 		utils.ServerErrorResponse(c, 500, "Error generating file path", utils.ErrGeneratingFilePath, err)
 		return
 	}
 
-	if err := uploadToS3(filePath, contentType, src.Bytes()); err != nil {
-		utils.ServerErrorResponse(c, 500, "Error uploading file to S3", utils.ErrS3UploadFailed, err)
+	if err := h.Storage.Put(c, filePath, contentType, src.Bytes()); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error uploading file", utils.ErrS3UploadFailed, err)
 		return
 	}
+	metrics.ImageUploads.Inc()
+	metrics.ImageUploadBytes.Add(float64(src.Len()))
 
 	// Save image metadata in the database
-	if err := saveImageMetadata(imageID, userID, filePath); err != nil {
+	if err := h.saveImageMetadata(c, imageID, userID, filePath); err != nil {
 		utils.ServerErrorResponse(c, 500, "Error saving image metadata", utils.ErrSaveData, err)
 		return
 	}
 
 	// Construct the file URL and send the response
-	fileURL := fmt.Sprintf("%s/%s", store.StaticBucketUrl, filePath)
+	fileURL := h.Storage.URL(filePath)
 	utils.FullyResponse(c, 201, "File uploaded successfully", nil, fileURL)
 }
 
@@ -114,8 +115,8 @@ func parseUserID(c *gin.Context) (uint64, error) {
 }
 
 // validateCourseExistence checks if the course exists in the database
-func validateCourseExistence(courseID uint64) error {
-	_, result := queries.GetCourseInformation(courseID)
+func (h *Handler) validateCourseExistence(ctx context.Context, courseID uint64) error {
+	_, result := queries.GetCourseInformation(h.DB.WithContext(ctx), courseID)
 	if result.Error == gorm.ErrRecordNotFound {
 		return fmt.Errorf("course not found")
 	} else if result.Error != nil {
@@ -139,7 +140,7 @@ func validateImageType(srcFile io.Reader) (contentType string, err error) {
 	return contentType, nil
 }
 
-// generateFilePath generates a unique file path for the image in S3
+// generateFilePath generates a unique file path for the image in the blob store
 func generateFilePath(courseID uint64, filename string) (string, uint64, error) {
 	courseIDString := utils.Uint64ToStr(courseID)
 	imageID := encryption.GenerateID()
@@ -147,20 +148,9 @@ func generateFilePath(courseID uint64, filename string) (string, uint64, error)
 	return fmt.Sprintf("%s/%s-%s", courseIDString, utils.Uint64ToStr(imageID), cleanedFilename), imageID, nil
 }
 
-// uploadToS3 uploads the image to S3 with the generated file path
-func uploadToS3(filePath string, contentType string, fileContent []byte) error {
-	_, err := store.Client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket:      &store.StaticBucket,
-		Key:         &filePath,
-		Body:        bytes.NewReader(fileContent),
-		ContentType: &contentType, // Assuming contentType is set earlier
-	})
-	return err
-}
-
 // saveImageMetadata saves the image metadata (file path, course ID, user ID) in the database
-func saveImageMetadata(imageID, userID uint64, filePath string) error {
-	result := queries.CreateCourseImage(models.CourseImage{
+func (h *Handler) saveImageMetadata(ctx context.Context, imageID, userID uint64, filePath string) error {
+	result := queries.CreateCourseImage(h.DB.WithContext(ctx), models.CourseImage{
 		ImageLink: filePath,
 		ID:        imageID,
 		CreatorID: userID,
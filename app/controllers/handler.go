@@ -0,0 +1,13 @@
+package controllers
+
+import "github.com/instructhub/backend/app"
+
+// Handler serves the authentication and user endpoints with the services of the App
+type Handler struct {
+	*app.App
+}
+
+// NewHandler creates the authentication and user handler
+func NewHandler(app *app.App) *Handler {
+	return &Handler{App: app}
+}
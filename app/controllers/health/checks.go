@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// checkResult is the outcome of the probe of a dependency
+type checkResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// checkDependencies probes every dependency at once, each with its own timeout
+func (h *Handler) checkDependencies(ctx context.Context) (results map[string]checkResult, healthy bool) {
+	checks := map[string]func(ctx context.Context) error{
+		"postgres": func(ctx context.Context) error {
+			sqlDB, err := h.DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"redis": func(ctx context.Context) error {
+			return h.Cache.Ping(ctx).Err()
+		},
+		"redis_limiter": func(ctx context.Context) error {
+			return h.Limiter.Ping(ctx).Err()
+		},
+		"storage": h.Storage.Ping,
+		"content": h.Content.Ping,
+	}
+
+	results = make(map[string]checkResult, len(checks))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.Config.Server.HealthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := checkResult{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+
+			mutex.Lock()
+			results[name] = result
+			mutex.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	healthy = true
+	for _, result := range results {
+		healthy = healthy && result.Status == "ok"
+	}
+	return results, healthy
+}
@@ -0,0 +1,13 @@
+package health
+
+import "github.com/instructhub/backend/app"
+
+// Handler serves the health and status endpoints with the services of the App
+type Handler struct {
+	*app.App
+}
+
+// NewHandler creates the health handler
+func NewHandler(app *app.App) *Handler {
+	return &Handler{App: app}
+}
@@ -0,0 +1,12 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// Liveness answers as long as the process serves requests, the dependencies aren't probed
+// so an outage of one of them doesn't get the instance restarted
+func (h *Handler) Liveness(c *gin.Context) {
+	utils.FullyResponse(c, 200, "OK", nil, nil)
+}
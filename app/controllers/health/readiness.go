@@ -0,0 +1,31 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Readiness probes every dependency, the instance shouldn't get traffic when one can't be reached.
+// The errors are only logged since the endpoint is public, they can name hosts and users
+func (h *Handler) Readiness(c *gin.Context) {
+	if h.Draining() {
+		utils.FullyResponse(c, 503, "Shutting down", utils.ErrServiceUnavailable, nil)
+		return
+	}
+
+	checks, healthy := h.checkDependencies(c.Request.Context())
+	for name, check := range checks {
+		if check.Error != "" {
+			h.Logger.Warn("Dependency check failed", zap.String("dependency", name), zap.String("error", check.Error))
+			check.Error = ""
+			checks[name] = check
+		}
+	}
+
+	if !healthy {
+		utils.FullyResponse(c, 503, "Some dependencies can't be reached", utils.ErrServiceUnavailable, checks)
+		return
+	}
+	utils.FullyResponse(c, 200, "Ready", nil, checks)
+}
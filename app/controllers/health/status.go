@@ -0,0 +1,91 @@
+package health
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+type databasePoolStatus struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMS     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+type redisPoolStatus struct {
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"total_connections"`
+	IdleConns  uint32 `json:"idle_connections"`
+	StaleConns uint32 `json:"stale_connections"`
+}
+
+type statusResponse struct {
+	Version       string                     `json:"version"`
+	Profile       string                     `json:"profile"`
+	GoVersion     string                     `json:"go_version"`
+	MachineID     uint16                     `json:"machine_id"`
+	StartedAt     time.Time                  `json:"started_at"`
+	UptimeSeconds int64                      `json:"uptime_seconds"`
+	Goroutines    int                        `json:"goroutines"`
+	Database      databasePoolStatus         `json:"database"`
+	Redis         map[string]redisPoolStatus `json:"redis"`
+	Dependencies  map[string]checkResult     `json:"dependencies"`
+}
+
+// Status describes the running instance and the state of its connection pools
+func (h *Handler) Status(c *gin.Context) {
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error getting database pool", utils.ErrGetData, err)
+		return
+	}
+	stats := sqlDB.Stats()
+	checks, _ := h.checkDependencies(c.Request.Context())
+
+	utils.FullyResponse(c, 200, "Successfully get status", nil, statusResponse{
+		Version:       h.Config.Server.Version,
+		Profile:       h.Config.Profile,
+		GoVersion:     runtime.Version(),
+		MachineID:     h.Config.Server.MachineID,
+		StartedAt:     h.StartedAt,
+		UptimeSeconds: int64(time.Since(h.StartedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		Database: databasePoolStatus{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDurationMS:     stats.WaitDuration.Milliseconds(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		},
+		Redis: map[string]redisPoolStatus{
+			"cache":   redisPool(h.Cache),
+			"limiter": redisPool(h.Limiter),
+		},
+		Dependencies: checks,
+	})
+}
+
+func redisPool(client *redis.Client) redisPoolStatus {
+	stats := client.PoolStats()
+	return redisPoolStatus{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		StaleConns: stats.StaleConns,
+	}
+}
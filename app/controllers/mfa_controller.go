@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/metrics"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// mfaPendingExpires is how long the second factor can be given after the password
+	mfaPendingExpires = 5 * time.Minute
+	// recoveryCodeCount is how many recovery codes are given when enabling the two-factor authentication
+	recoveryCodeCount = 10
+	// totpIssuer names the account in the authenticator apps
+	totpIssuer = "InstructHub"
+	// totpQRCodeSize is the width and height of the QR code in pixels
+	totpQRCodeSize = 256
+	// totpUsedExpires covers the window a code is accepted in, the 30 seconds of its step and the ones around
+	totpUsedExpires = 90 * time.Second
+)
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	// QRCode is the PNG of the URI, base64 encoded
+	QRCode string `json:"qr_code"`
+}
+
+type TOTPCodeRequest struct {
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP generates a TOTP secret for the user, it is used once confirmed with ConfirmTOTP
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	if !encryption.HasSecretKey() {
+		utils.FullyResponse(c, 503, "Two-factor authentication isn't available", utils.ErrMFAUnavailable, nil)
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		utils.FullyResponse(c, 400, "Two-factor authentication is already enabled", utils.ErrMFAAlreadyEnabled, nil)
+		return
+	}
+	if _, ok := h.requireRecentLogin(c, user, "enable two-factor authentication"); !ok {
+		return
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: totpIssuer, AccountName: user.Email})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generating TOTP secret", utils.ErrGenerateToken, err)
+		return
+	}
+	encryptedSecret, err := encryption.EncryptSecret(key.Secret())
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error encrypting TOTP secret", utils.ErrHashData, err)
+		return
+	}
+
+	image, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generating QR code", utils.ErrGenerateToken, err)
+		return
+	}
+	var qrCode bytes.Buffer
+	if err := png.Encode(&qrCode, image); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generating QR code", utils.ErrGenerateToken, err)
+		return
+	}
+
+	// A previous enrollment that wasn't confirmed is replaced
+	result := queries.UpdateUserTOTP(h.DB.WithContext(c), user.ID, encryptedSecret, false)
+	if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error saving TOTP secret", utils.ErrSaveData, result.Error)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Add the account to an authenticator app then confirm with a code", nil, TOTPEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: base64.StdEncoding.EncodeToString(qrCode.Bytes()),
+	})
+}
+
+// ConfirmTOTP enables the two-factor authentication with a code of the enrolled secret and returns the recovery codes
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	var request TOTPCodeRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		utils.FullyResponse(c, 400, "Two-factor authentication is already enabled", utils.ErrMFAAlreadyEnabled, nil)
+		return
+	}
+	if user.TOTPSecret == "" {
+		utils.FullyResponse(c, 400, "Enroll an authenticator app first", utils.ErrMFANotEnrolled, nil)
+		return
+	}
+
+	valid, err := h.validateTOTP(c, user, request.Code)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error checking code", utils.ErrGetData, err)
+		return
+	}
+	if !valid {
+		utils.FullyResponse(c, 400, "Invalid code", utils.ErrInvalidMFACode, nil)
+		return
+	}
+
+	codes, recoveryCodes, err := generateRecoveryCodes(user.ID)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generating recovery codes", utils.ErrGenerateToken, err)
+		return
+	}
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := queries.UpdateUserTOTP(tx, user.ID, user.TOTPSecret, true).Error; err != nil {
+			return err
+		}
+		return queries.ReplaceRecoveryCodes(tx, user.ID, recoveryCodes)
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error enabling two-factor authentication", utils.ErrSaveData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Two-factor authentication enabled, keep the recovery codes somewhere safe", nil, RecoveryCodes{Codes: codes})
+}
+
+// DisableTOTP turns the two-factor authentication off with a TOTP or a recovery code
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	var request TOTPCodeRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		utils.FullyResponse(c, 400, "Two-factor authentication isn't enabled", utils.ErrMFANotEnabled, nil)
+		return
+	}
+	if _, ok := h.requireRecentLogin(c, user, "disable two-factor authentication"); !ok {
+		return
+	}
+
+	valid, err := h.verifySecondFactor(c, user, request.Code)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error checking code", utils.ErrGetData, err)
+		return
+	}
+	if !valid {
+		utils.FullyResponse(c, 400, "Invalid code", utils.ErrInvalidMFACode, nil)
+		return
+	}
+
+	err = h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := queries.UpdateUserTOTP(tx, user.ID, "", false).Error; err != nil {
+			return err
+		}
+		return queries.DeleteUserRecoveryCodes(tx, user.ID).Error
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error disabling two-factor authentication", utils.ErrSaveData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Two-factor authentication disabled", nil, nil)
+}
+
+// LoginMFA finishes a login with a TOTP or a recovery code, the mfa_pending cookie tells who gave their password
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var request TOTPCodeRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "Please log in first", utils.ErrUnauthorized, nil)
+		return
+	}
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 403, "Please log in first", utils.ErrUnauthorized, nil)
+		return
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error get user data", utils.ErrGetData, result.Error)
+		return
+	}
+	// Disabled in the meantime
+	if !user.TOTPEnabled {
+		utils.FullyResponse(c, 403, "Please log in again", utils.ErrUnauthorized, nil)
+		return
+	}
+
+	valid, err := h.verifySecondFactor(c, user, request.Code)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error checking code", utils.ErrGetData, err)
+		return
+	}
+	if !valid {
+		metrics.Logins.WithLabelValues("totp", "failure").Inc()
+		utils.FullyResponse(c, 400, "Invalid code", utils.ErrInvalidMFACode, nil)
+		return
+	}
+
+	c.SetCookie("mfa_pending", "", -1, "/", "", false, true)
+	err = utils.GenerateUserSession(c, h.DB.WithContext(c), user.ID, time.Now())
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Internal server error", utils.ErrGenerateSession, err)
+		return
+	}
+	metrics.Logins.WithLabelValues("totp", "success").Inc()
+
+	utils.FullyResponse(c, 200, "Login successful", nil, nil)
+}
+
+// beginLogin starts the session of a user who gave their password or logged in with OAuth, or asks for the
+// second factor first when it is enabled, mfaRequired tells which happened
+func (h *Handler) beginLogin(c *gin.Context, user models.User) (mfaRequired bool, err error) {
+	if !user.TOTPEnabled {
+		return false, utils.GenerateUserSession(c, h.DB.WithContext(c), user.ID, time.Now())
+	}
+
+	mfaPendingToken, err := encryption.GenerateNewJwtToken(user.ID, []string{"mfa_pending"}, time.Now().Add(mfaPendingExpires))
+	if err != nil {
+		return false, err
+	}
+	c.SetCookie("mfa_pending", mfaPendingToken, int(mfaPendingExpires.Seconds()), "/", "", utils.SecureCookie, true)
+	return true, nil
+}
+
+// verifySecondFactor checks a TOTP code, or uses up a recovery code
+func (h *Handler) verifySecondFactor(ctx context.Context, user models.User, code string) (bool, error) {
+	code = normalizeCode(code)
+	if len(code) == 6 {
+		return h.validateTOTP(ctx, user, code)
+	}
+
+	result := queries.UseRecoveryCode(h.DB.WithContext(ctx), user.ID, hashRecoveryCode(code), time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// validateTOTP checks a code of the TOTP secret of the user, a code is only accepted once
+func (h *Handler) validateTOTP(ctx context.Context, user models.User, code string) (bool, error) {
+	secret, err := encryption.DecryptSecret(user.TOTPSecret)
+	if err != nil {
+		return false, err
+	}
+	code = normalizeCode(code)
+	if !totp.Validate(code, secret) {
+		return false, nil
+	}
+
+	// Remembered until it expires so a code seen by someone else can't be replayed
+	return h.Cache.SetNX(ctx, fmt.Sprintf("totp_used:%d:%s", user.ID, code), 1, totpUsedExpires).Result()
+}
+
+// generateRecoveryCodes returns the codes to show to the user and their hashes to store
+func generateRecoveryCodes(userID uint64) ([]string, []models.RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	recoveryCodes := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		random := make([]byte, 10)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+		// 16 characters shown in groups of 4
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(random))
+		codes = append(codes, code[0:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:16])
+		recoveryCodes = append(recoveryCodes, models.RecoveryCode{
+			ID:        encryption.GenerateID(),
+			UserID:    userID,
+			CodeHash:  hashRecoveryCode(code),
+			CreatedAt: time.Now(),
+		})
+	}
+	return codes, recoveryCodes, nil
+}
+
+// hashRecoveryCode hashes a normalized recovery code
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode drops the separators the users may type
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
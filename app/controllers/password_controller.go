@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/encryption"
+	"github.com/instructhub/backend/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// passwordResetExpires is how long a reset link can be used
+	passwordResetExpires = 30 * time.Minute
+	// passwordResetSendTimeout bounds looking up the user and sending the reset email after answering
+	passwordResetSendTimeout = time.Minute
+)
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email,max=320"`
+}
+
+// RequestPasswordReset sends a reset link to the email, the answer is the same whether the email is known or not
+func (h *Handler) RequestPasswordReset(c *gin.Context) {
+	var request PasswordResetRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	// The email is looked up and sent after answering, so neither the response time nor a mailer failure
+	// tells whether it is registered
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), passwordResetSendTimeout)
+	go func() {
+		defer cancel()
+		if err := h.requestPasswordReset(ctx, request.Email); err != nil {
+			h.Logger.Error("Error sending password reset email", zap.Error(err))
+		}
+	}()
+
+	utils.FullyResponse(c, 200, "If the email is registered, a password reset link was sent to it", nil, nil)
+}
+
+// requestPasswordReset sends a reset link when the email belongs to a user with a password
+func (h *Handler) requestPasswordReset(ctx context.Context, email string) error {
+	user, result := queries.GetUserQueueByEmail(h.DB.WithContext(ctx), email)
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil
+	} else if result.Error != nil {
+		return result.Error
+	}
+
+	// The OAuth users have no password to reset
+	if user.Password == "" {
+		return nil
+	}
+	return h.sendPasswordResetEmail(ctx, user)
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,max=128,min=8"`
+}
+
+// ResetPassword sets the new password with a reset token and signs out every session of the user
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var request ConfirmPasswordResetRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	// The token is deleted as it is read so it can only be used once
+	userIDString, err := h.Cache.GetDel(c, passwordResetKey(request.Token)).Result()
+	if err == redis.Nil {
+		utils.FullyResponse(c, 400, "Invalid or expired reset token", utils.ErrInvalidResetToken, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error accessing Redis", utils.ErrGetData, err)
+		return
+	}
+	userID, err := utils.StrToUint64(userIDString)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error parsing user ID", utils.ErrParseData, err)
+		return
+	}
+	if err := h.Cache.Del(c, passwordResetUserKey(userID)).Err(); err != nil {
+		c.Error(err)
+	}
+
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 400, "Invalid or expired reset token", utils.ErrInvalidResetToken, nil)
+		return
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error get user data", utils.ErrGetData, result.Error)
+		return
+	}
+
+	if err := h.replacePassword(c, user.ID, request.Password, true, ""); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating password", utils.ErrSaveData, err)
+		return
+	}
+	// The reset proves the user owns the email, a lock set by someone guessing the old password is lifted
+	if err := h.Lockout.Unlock(c, user.Email); err != nil {
+		c.Error(err)
+	}
+
+	// Clear the cookies of this browser, its session was revoked with the others
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+	c.SetCookie("access_token", "", -1, "/", "", false, false)
+
+	utils.FullyResponse(c, 200, "Password reset, please log in again", nil, nil)
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword      string `json:"current_password" binding:"required,max=128"`
+	NewPassword          string `json:"new_password" binding:"required,max=128,min=8"`
+	SignOutOtherSessions bool   `json:"sign_out_other_sessions"`
+}
+
+// ChangePassword replaces the password of the user after checking the current one
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var request ChangePasswordRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.Password == "" {
+		utils.FullyResponse(c, 400, "The account has no password, set one first", utils.ErrPasswordNotSet, nil)
+		return
+	}
+
+	match, err := encryption.ComparePasswordAndHash(request.CurrentPassword, user.Password)
+	if err != nil || !match {
+		utils.FullyResponse(c, 400, "Invalid password", utils.ErrInvalidPassword, nil)
+		return
+	}
+
+	// Without a refresh token every session is signed out, this one included
+	refreshToken, _ := c.Cookie("refresh_token")
+	if err := h.replacePassword(c, user.ID, request.NewPassword, request.SignOutOtherSessions, refreshToken); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating password", utils.ErrSaveData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Password changed", nil, nil)
+}
+
+type SetPasswordRequest struct {
+	Password             string `json:"password" binding:"required,max=128,min=8"`
+	SignOutOtherSessions bool   `json:"sign_out_other_sessions"`
+}
+
+// SetPassword adds a password to an account created with OAuth, the user must have logged in
+// within REAUTHENTICATION_WINDOW since a stolen session shouldn't be enough to take the account over
+func (h *Handler) SetPassword(c *gin.Context) {
+	var request SetPasswordRequest
+
+	// Validate request body
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.Password != "" {
+		utils.FullyResponse(c, 400, "The account already has a password, change it instead", utils.ErrPasswordAlreadySet, nil)
+		return
+	}
+
+	refreshToken, ok := h.requireRecentLogin(c, user, "set a password")
+	if !ok {
+		return
+	}
+
+	if err := h.replacePassword(c, user.ID, request.Password, request.SignOutOtherSessions, refreshToken); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error updating password", utils.ErrSaveData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Password set, you can now log in with your email", nil, nil)
+}
+
+// currentUser fetches the user of the access token, the response is already written when ok is false
+func (h *Handler) currentUser(c *gin.Context) (user models.User, ok bool) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return user, false
+	}
+
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 403, "UserID error", utils.ErrGetData, nil)
+		return user, false
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error get user data", utils.ErrGetData, result.Error)
+		return user, false
+	}
+	return user, true
+}
+
+// requireRecentLogin checks the session of this browser logged in within REAUTHENTICATION_WINDOW, for the changes
+// a stolen session shouldn't be enough for. The response is already written when ok is false.
+func (h *Handler) requireRecentLogin(c *gin.Context, user models.User, action string) (refreshToken string, ok bool) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil {
+		utils.FullyResponse(c, 403, "Please log in again to "+action, utils.ErrReauthenticationRequired, nil)
+		return "", false
+	}
+	session, result := queries.GetSessionQueueBySecretKey(h.DB.WithContext(c), refreshToken)
+	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+		utils.ServerErrorResponse(c, 500, "Error get session", utils.ErrGetData, result.Error)
+		return "", false
+	}
+	if result.Error == gorm.ErrRecordNotFound || session.UserID != user.ID ||
+		time.Since(session.AuthenticatedAt) > h.Config.Auth.ReauthenticationWindow {
+		utils.FullyResponse(c, 403, "Please log in again to "+action, utils.ErrReauthenticationRequired, nil)
+		return "", false
+	}
+	return refreshToken, true
+}
+
+// replacePassword hashes and saves the new password. signOut revokes every session of the user but the one of
+// keepSecretKey, the access tokens already issued stay valid until they expire.
+func (h *Handler) replacePassword(ctx context.Context, userID uint64, password string, signOut bool, keepSecretKey string) error {
+	hashedPassword, err := encryption.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+
+	return h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := queries.UpdateUserPassword(tx, userID, hashedPassword); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		switch {
+		case !signOut:
+			return nil
+		case keepSecretKey == "":
+			return queries.DeleteUserSessions(tx, userID).Error
+		default:
+			return queries.DeleteOtherUserSessions(tx, userID, keepSecretKey).Error
+		}
+	})
+}
+
+// sendPasswordResetEmail stores a single-use reset token and sends its link to the user
+func (h *Handler) sendPasswordResetEmail(ctx context.Context, user models.User) error {
+	resetToken, err := encryption.GenerateRandomBase64String(64)
+	if err != nil {
+		return fmt.Errorf("error generating reset token: %w", err)
+	}
+
+	data := struct {
+		ResetURL  string
+		UserName  string
+		ExpiresIn string
+	}{
+		ResetURL:  utils.FrontendURl + "/password/reset?token=" + url.QueryEscape(resetToken),
+		UserName:  user.Username,
+		ExpiresIn: "30 minutes",
+	}
+
+	var emailBody bytes.Buffer
+	t, err := template.New("Password reset").ParseFiles("template/password_reset.html")
+	if err != nil {
+		return fmt.Errorf("error parsing email template: %w", err)
+	}
+	if err := t.ExecuteTemplate(&emailBody, "password_reset.html", data); err != nil {
+		return fmt.Errorf("error executing email template: %w", err)
+	}
+
+	// Stored before sending so the link works as soon as it arrives
+	if err := h.Cache.Set(ctx, passwordResetKey(resetToken), user.ID, passwordResetExpires).Err(); err != nil {
+		return err
+	}
+	// A user has one reset token at a time, the link of the previous request stops working
+	previousToken, err := h.Cache.SetArgs(ctx, passwordResetUserKey(user.ID), resetToken, redis.SetArgs{Get: true, TTL: passwordResetExpires}).Result()
+	if err == nil {
+		if err := h.Cache.Del(ctx, passwordResetKey(previousToken)).Err(); err != nil {
+			return err
+		}
+	} else if err != redis.Nil {
+		return err
+	}
+	return h.Mailer.Send(user.Email, "Reset your password", emailBody.String())
+}
+
+// passwordResetKey is the Redis key of a reset token
+func passwordResetKey(token string) string {
+	return "password_reset:" + token
+}
+
+// passwordResetUserKey is the Redis key of the current reset token of a user
+func passwordResetUserKey(userID uint64) string {
+	return "password_reset_user:" + utils.Uint64ToStr(userID)
+}
@@ -13,7 +13,7 @@ func CheckLogin(c *gin.Context) {
 	utils.FullyResponse(c, 200, "User already login", nil, nil)
 }
 
-func GetProfile(c *gin.Context) {
+func (h *Handler) GetProfile(c *gin.Context) {
 	jwtContextID, exists := c.Get("userID")
 	if !exists {
 		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
@@ -22,7 +22,7 @@ func GetProfile(c *gin.Context) {
 
 	userID := jwtContextID.(uint64)
 
-	user, result := queries.GetUserQueueByID(userID)
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
 	if result.Error == gorm.ErrRecordNotFound {
 		utils.FullyResponse(c, 403, "UserID error", utils.ErrGetData, nil)
 		return
@@ -37,6 +37,32 @@ func GetProfile(c *gin.Context) {
 		utils.ServerErrorResponse(c, 500, "Error process image", utils.ErrChangeType, err)
 		return
 	}
+	userProfile.HasPassword = user.Password != ""
 
 	utils.FullyResponse(c, 200, "User profile acquire", nil, userProfile)
 }
+
+// UnlockUser lifts the lock set after too many failed logins, for the admins
+func (h *Handler) UnlockUser(c *gin.Context) {
+	userID, err := utils.StrToUint64(c.Param("userID"))
+	if err != nil {
+		utils.FullyResponse(c, 400, "Invalid user ID", utils.ErrBadRequest, nil)
+		return
+	}
+
+	user, result := queries.GetUserQueueByID(h.DB.WithContext(c), userID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 404, "User not found", utils.ErrUserNotFound, nil)
+		return
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error get user data", utils.ErrGetData, result.Error)
+		return
+	}
+
+	if err := h.Lockout.Unlock(c, user.Email); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error unlocking user", utils.ErrDeleteData, err)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "User unlocked", nil, nil)
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dir is where the migrations are written from the root of the repository
+const Dir = "app/migrations/sql"
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes the empty up and down files of a new migration in dir, numbered after the last one
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("the migration name must contain letters or digits")
+	}
+
+	migrations, err := load(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, err
+	}
+	version := uint64(1)
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	paths := []string{}
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s: %s\n", strings.ToUpper(direction), strings.ReplaceAll(name, "_", " "))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
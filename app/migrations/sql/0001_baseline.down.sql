@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS revision_reviews;
+DROP TABLE IF EXISTS revision_comments;
+DROP TABLE IF EXISTS revision_commits;
+DROP TABLE IF EXISTS course_revisions;
+DROP TABLE IF EXISTS course_members;
+DROP TABLE IF EXISTS course_landing_pages;
+DROP TABLE IF EXISTS course_images;
+DROP TABLE IF EXISTS course_steps;
+DROP TABLE IF EXISTS course_modules;
+DROP TABLE IF EXISTS courses;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS oauth_providers;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,210 @@
+-- Schema of the models as created by AutoMigrate before the migrations were versioned.
+-- IF NOT EXISTS lets the databases created by AutoMigrate adopt it without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial,
+    avatar text,
+    username text,
+    display_name text,
+    email text,
+    password text,
+    verify boolean,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT uni_users_username UNIQUE (username),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS oauth_providers (
+    id bigserial,
+    user_id bigint NOT NULL,
+    provider smallint NOT NULL,
+    o_auth_id text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_oauth_providers FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT uni_oauth_providers_o_auth_id UNIQUE (o_auth_id)
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_providers_user_id ON oauth_providers (user_id);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id bigserial,
+    secret_key text NOT NULL,
+    user_agent varchar(512),
+    user_id bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (session_id),
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uni_sessions_secret_key UNIQUE (secret_key)
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_secret_key ON sessions (secret_key);
+
+CREATE TABLE IF NOT EXISTS courses (
+    id bigserial,
+    creator_id bigint NOT NULL,
+    name varchar(255) NOT NULL,
+    description text,
+    private boolean NOT NULL DEFAULT false,
+    updated_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS course_modules (
+    id bigserial,
+    course_id bigint NOT NULL,
+    position bigint,
+    name varchar(255) NOT NULL,
+    updated_at timestamptz,
+    created_at timestamptz,
+    active boolean DEFAULT true,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_courses_course_modules FOREIGN KEY (course_id) REFERENCES courses(id)
+);
+CREATE INDEX IF NOT EXISTS idx_course_modules_course_id ON course_modules (course_id);
+
+CREATE TABLE IF NOT EXISTS course_steps (
+    id bigserial,
+    module_id bigint NOT NULL,
+    position bigint,
+    type smallint NOT NULL,
+    name varchar(255) NOT NULL,
+    active boolean DEFAULT true,
+    updated_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_course_modules_course_steps FOREIGN KEY (module_id) REFERENCES course_modules(id)
+);
+CREATE INDEX IF NOT EXISTS idx_course_steps_module_id ON course_steps (module_id);
+
+CREATE TABLE IF NOT EXISTS course_images (
+    id bigserial,
+    image_link varchar(512) NOT NULL,
+    creator_id bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS course_landing_pages (
+    course_id bigint NOT NULL,
+    description text,
+    image_url text,
+    video_url text,
+    seo_keywords text[],
+    outcomes text[],
+    prerequisites text[],
+    target_audience text[],
+    updated_at timestamptz,
+    created_at timestamptz,
+    CONSTRAINT fk_courses_course_landing_page FOREIGN KEY (course_id) REFERENCES courses(id)
+);
+
+CREATE TABLE IF NOT EXISTS course_members (
+    id bigserial,
+    course_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    role smallint NOT NULL,
+    updated_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_course_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_course_members_course FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_course_members_user_id ON course_members (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_course_member ON course_members (course_id, user_id);
+
+CREATE TABLE IF NOT EXISTS course_revisions (
+    id bigserial,
+    course_id bigint NOT NULL,
+    branch_id bigint,
+    base_commit varchar(64),
+    pull_request_id bigint,
+    description text,
+    status smallint,
+    editor_id bigint,
+    approver_id bigint,
+    close_reason text,
+    closed_by_id bigint,
+    closed_at timestamptz,
+    status_before_lock smallint,
+    branch_deleted boolean NOT NULL DEFAULT false,
+    updated_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_course_revisions_approver FOREIGN KEY (approver_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_course_revisions_course FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_course_revisions_editor FOREIGN KEY (editor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_course_revisions_closed_at ON course_revisions (closed_at);
+CREATE INDEX IF NOT EXISTS idx_course_revisions_approver_id ON course_revisions (approver_id);
+CREATE INDEX IF NOT EXISTS idx_course_revisions_editor_id ON course_revisions (editor_id);
+CREATE INDEX IF NOT EXISTS idx_course_revisions_course_id ON course_revisions (course_id);
+
+CREATE TABLE IF NOT EXISTS revision_commits (
+    id bigserial,
+    revision_id bigint NOT NULL,
+    sha varchar(64) NOT NULL,
+    parent_sha varchar(64),
+    author_id bigint NOT NULL,
+    message text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_revision_commits_revision FOREIGN KEY (revision_id) REFERENCES course_revisions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_revision_commits_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_revision_commits_author_id ON revision_commits (author_id);
+CREATE INDEX IF NOT EXISTS idx_revision_commits_revision_id ON revision_commits (revision_id);
+
+CREATE TABLE IF NOT EXISTS revision_comments (
+    id bigserial,
+    revision_id bigint NOT NULL,
+    parent_id bigint,
+    step_id bigint,
+    author_id bigint NOT NULL,
+    body text NOT NULL,
+    gitea_comment_id bigint,
+    updated_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_revision_comments_revision FOREIGN KEY (revision_id) REFERENCES course_revisions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_revision_comments_parent FOREIGN KEY (parent_id) REFERENCES revision_comments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_revision_comments_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_revision_comments_revision_id ON revision_comments (revision_id);
+CREATE INDEX IF NOT EXISTS idx_revision_comments_author_id ON revision_comments (author_id);
+CREATE INDEX IF NOT EXISTS idx_revision_comments_parent_id ON revision_comments (parent_id);
+
+CREATE TABLE IF NOT EXISTS revision_reviews (
+    id bigserial,
+    revision_id bigint NOT NULL,
+    reviewer_id bigint NOT NULL,
+    verdict smallint,
+    body text,
+    gitea_review_id bigint,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_revision_reviews_revision FOREIGN KEY (revision_id) REFERENCES course_revisions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_revision_reviews_reviewer FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_revision_reviews_reviewer_id ON revision_reviews (reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_revision_reviews_revision_id ON revision_reviews (revision_id);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial,
+    type varchar(64) NOT NULL,
+    idempotency_key varchar(255) NOT NULL,
+    payload text NOT NULL,
+    status smallint NOT NULL DEFAULT 0,
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz NOT NULL,
+    updated_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox_events (status, next_attempt_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_idempotency_key ON outbox_events (idempotency_key);
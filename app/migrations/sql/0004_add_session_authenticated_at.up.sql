@@ -0,0 +1,3 @@
+ALTER TABLE sessions ADD COLUMN authenticated_at timestamptz;
+UPDATE sessions SET authenticated_at = COALESCE(created_at, now());
+ALTER TABLE sessions ALTER COLUMN authenticated_at SET NOT NULL;
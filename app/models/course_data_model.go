@@ -0,0 +1,33 @@
+package models
+
+// CourseDefaultBranch is the branch holding the published content of every course
+const CourseDefaultBranch = "en"
+
+// CourseDataFile is the file of every course repository holding its structure
+const CourseDataFile = "course_data.json"
+
+// CourseDataStep is a step of course_data.json, revisions also send the content of the changed steps
+type CourseDataStep struct {
+	ID       *string    `json:"id" binding:"omitempty,number"`
+	ModuleID *string    `json:"module_id,omitempty" binding:"omitempty,number"`
+	Position int        `json:"position" binding:"required"`
+	Type     CourseType `json:"type" binding:"number"`
+	Name     string     `json:"name" binding:"max=50"`
+	Updated  *bool      `json:"updated,omitempty" binding:"omitempty"`
+	Content  *string    `json:"content,omitempty" binding:"omitempty,base64,max=100000"`
+}
+
+// CourseDataModule is a module of course_data.json
+type CourseDataModule struct {
+	ID       *string `json:"id" binding:"omitempty,number"`
+	Position int     `json:"position" binding:"required,number"`
+	Name     string  `json:"name" binding:"required,max=30"`
+
+	CourseSteps []CourseDataStep `json:"course_steps" binding:"max=20,dive"`
+}
+
+// CourseData is the course structure saved in course_data.json
+type CourseData struct {
+	Modules     []CourseDataModule `json:"modules" binding:"min=1,max=10,dive"`
+	Description string             `json:"description" binding:"required,max=100"`
+}
@@ -4,25 +4,16 @@ import (
 	"strings"
 	"time"
 
-	db "github.com/instructhub/backend/pkg/database"
 	pq "github.com/lib/pq"
 )
 
-func init() {
-	db.GetDB().AutoMigrate(&Course{})
-	db.GetDB().AutoMigrate(&CourseModule{})
-	db.GetDB().AutoMigrate(&CourseStep{})
-	db.GetDB().AutoMigrate(&CourseImage{})
-	db.GetDB().AutoMigrate(&CourseRevision{})
-	db.GetDB().AutoMigrate(&CourseLandingPage{})
-}
-
 // Course type / table
 type Course struct {
 	ID          uint64    `json:"id,string" gorm:"primaryKey"`
 	CreatorID   uint64    `json:"creator_id,string" gorm:"not null"`
 	Name        string    `json:"name" gorm:"not null;size:255"`
 	Description string    `json:"description" gorm:"type:text"`
+	Private     bool      `json:"private" gorm:"not null;default:false"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 
@@ -97,25 +88,150 @@ const (
 	RevisionMerged
 	// No one can leave comment or update anything
 	RevisionLock
+	// Approved, the course and the pull request are being merged
+	RevisionMerging
+)
+
+var (
+	revisionStatusMap = map[string]RevisionStatus{
+		"open":    RevisionOpen,
+		"closed":  RevisionClose,
+		"merged":  RevisionMerged,
+		"locked":  RevisionLock,
+		"merging": RevisionMerging,
+	}
 )
 
+func ParseStringToRevisionStatus(str string) (RevisionStatus, bool) {
+	s, ok := revisionStatusMap[strings.ToLower(str)]
+	return s, ok
+}
+
+func (s RevisionStatus) String() string {
+	for name, status := range revisionStatusMap {
+		if status == s {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Allowed revision status changes, a merged revision can't change anymore
+var revisionTransitions = map[RevisionStatus][]RevisionStatus{
+	RevisionOpen:    {RevisionClose, RevisionMerging, RevisionLock},
+	RevisionClose:   {RevisionOpen, RevisionLock},
+	RevisionLock:    {RevisionOpen, RevisionClose},
+	RevisionMerging: {RevisionMerged, RevisionOpen},
+}
+
+// CanTransitionTo checks if the revision can go from this status to the next one
+func (s RevisionStatus) CanTransitionTo(next RevisionStatus) bool {
+	for _, allowed := range revisionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type CourseRevision struct {
-	ID            uint64         `json:"id,string" gorm:"primaryKey"`
-	CourseID      uint64         `json:"course_id,string" gorm:"not null;index"`
-	BranchID      uint64         `json:"branch_id,string"`
-	PullRequestID int            `json:"pull_request_id"`
-	Description   string         `json:"description"`
-	Status        RevisionStatus `json:"status"`
-	EditorID      uint64         `json:"editor_id,string" gorm:"index"`
-	ApproverID    *uint64        `json:"approver_id,string" gorm:"index"`
-	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	ID               uint64          `json:"id,string" gorm:"primaryKey"`
+	CourseID         uint64          `json:"course_id,string" gorm:"not null;index"`
+	BranchID         uint64          `json:"branch_id,string"`
+	BaseCommit       string          `json:"base_commit" gorm:"size:64"` // Commit of the base branch the revision branch started from
+	PullRequestID    int             `json:"pull_request_id"`
+	Description      string          `json:"description"`
+	Status           RevisionStatus  `json:"status"`
+	EditorID         uint64          `json:"editor_id,string" gorm:"index"`
+	ApproverID       *uint64         `json:"approver_id,string" gorm:"index"`
+	CloseReason      string          `json:"close_reason,omitempty"`
+	ClosedByID       *uint64         `json:"closed_by_id,string,omitempty"`
+	ClosedAt         *time.Time      `json:"closed_at,omitempty" gorm:"index"`
+	StatusBeforeLock *RevisionStatus `json:"-"` // Status to go back to when the revision is unlocked
+	BranchDeleted    bool            `json:"branch_deleted" gorm:"not null;default:false"`
+	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime"`
 
 	Course   *Course `json:"course,omitempty" gorm:"foreignKey:CourseID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
 	Editor   *User   `json:"editor,omitempty" gorm:"foreignKey:EditorID;references:ID;constraint:OnDelete:SET NULL"`
 	Approver *User   `json:"approver,omitempty" gorm:"foreignKey:ApproverID;references:ID;constraint:OnDelete:SET NULL"`
 }
 
+// RevisionCommit type / table, a commit pushed to the branch of a revision
+type RevisionCommit struct {
+	ID         uint64    `json:"id,string" gorm:"primaryKey"`
+	RevisionID uint64    `json:"revision_id,string" gorm:"not null;index"`
+	SHA        string    `json:"sha" gorm:"not null;size:64"`
+	ParentSHA  string    `json:"parent_sha,omitempty" gorm:"size:64"`
+	AuthorID   uint64    `json:"author_id,string" gorm:"not null;index"`
+	Message    string    `json:"message" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Revision *CourseRevision `json:"revision,omitempty" gorm:"foreignKey:RevisionID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	Author   *User           `json:"author,omitempty" gorm:"foreignKey:AuthorID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
+
+// RevisionComment type / table, a comment without parent starts a new thread
+type RevisionComment struct {
+	ID             uint64    `json:"id,string" gorm:"primaryKey"`
+	RevisionID     uint64    `json:"revision_id,string" gorm:"not null;index"`
+	ParentID       *uint64   `json:"parent_id,string,omitempty" gorm:"index"`
+	StepID         *uint64   `json:"step_id,string,omitempty"`
+	AuthorID       uint64    `json:"author_id,string" gorm:"not null;index"`
+	Body           string    `json:"body" gorm:"type:text;not null"`
+	GiteaCommentID *int64    `json:"-"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Revision *CourseRevision  `json:"revision,omitempty" gorm:"foreignKey:RevisionID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	Parent   *RevisionComment `json:"parent,omitempty" gorm:"foreignKey:ParentID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	Author   *User            `json:"author,omitempty" gorm:"foreignKey:AuthorID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
+
+type ReviewVerdict int8
+
+const (
+	ReviewComment ReviewVerdict = iota
+	ReviewApprove
+	ReviewRequestChanges
+)
+
+var (
+	reviewVerdictMap = map[string]ReviewVerdict{
+		"comment":         ReviewComment,
+		"approve":         ReviewApprove,
+		"request_changes": ReviewRequestChanges,
+	}
+)
+
+func ParseStringToReviewVerdict(str string) (ReviewVerdict, bool) {
+	v, ok := reviewVerdictMap[strings.ToLower(str)]
+	return v, ok
+}
+
+func (v ReviewVerdict) String() string {
+	for name, verdict := range reviewVerdictMap {
+		if verdict == v {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// RevisionReview type / table
+type RevisionReview struct {
+	ID            uint64        `json:"id,string" gorm:"primaryKey"`
+	RevisionID    uint64        `json:"revision_id,string" gorm:"not null;index"`
+	ReviewerID    uint64        `json:"reviewer_id,string" gorm:"not null;index"`
+	Verdict       ReviewVerdict `json:"verdict"`
+	Body          string        `json:"body" gorm:"type:text"`
+	GiteaReviewID *int64        `json:"-"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"autoCreateTime"`
+
+	Revision *CourseRevision `json:"revision,omitempty" gorm:"foreignKey:RevisionID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	Reviewer *User           `json:"reviewer,omitempty" gorm:"foreignKey:ReviewerID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
+
 type ProgessState int8
 
 const (
@@ -156,3 +272,49 @@ type CourseLandingPage struct {
 
 	Course *Course `json:"course,omitempty" gorm:"foreignKey:CourseID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
 }
+
+// CourseRole is ordered by privilege, a higher role includes every permission of the lower ones
+type CourseRole int8
+
+const (
+	CourseContributor CourseRole = iota
+	CourseReviewer
+	CourseMaintainer
+	CourseOwner
+)
+
+var (
+	courseRoleMap = map[string]CourseRole{
+		"contributor": CourseContributor,
+		"reviewer":    CourseReviewer,
+		"maintainer":  CourseMaintainer,
+		"owner":       CourseOwner,
+	}
+)
+
+func ParseStringToCourseRole(str string) (CourseRole, bool) {
+	r, ok := courseRoleMap[strings.ToLower(str)]
+	return r, ok
+}
+
+func (r CourseRole) String() string {
+	for name, role := range courseRoleMap {
+		if role == r {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// CourseMember type / table
+type CourseMember struct {
+	ID        uint64     `json:"id,string" gorm:"primaryKey"`
+	CourseID  uint64     `json:"course_id,string" gorm:"not null;uniqueIndex:idx_course_member"`
+	UserID    uint64     `json:"user_id,string" gorm:"not null;uniqueIndex:idx_course_member;index"`
+	Role      CourseRole `json:"role" gorm:"not null"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	Course *Course `json:"course,omitempty" gorm:"foreignKey:CourseID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	User   *User   `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+type OutboxEventType string
+
+const (
+	OutboxCreateCourseRepo OutboxEventType = "course.create_repo"
+	OutboxCreateRevision   OutboxEventType = "revision.create"
+	OutboxMirrorComment    OutboxEventType = "comment.mirror"
+	OutboxMirrorReview     OutboxEventType = "review.mirror"
+)
+
+type OutboxStatus int8
+
+const (
+	OutboxPending OutboxStatus = iota
+	OutboxDone
+	// Gave up after too many attempts
+	OutboxFailed
+)
+
+// OutboxEvent type / table, a Gitea side effect saved with the data it belongs to and performed later
+type OutboxEvent struct {
+	ID             uint64          `json:"id,string" gorm:"primaryKey"`
+	Type           OutboxEventType `json:"type" gorm:"not null;size:64"`
+	IdempotencyKey string          `json:"idempotency_key" gorm:"not null;size:255;uniqueIndex"`
+	Payload        string          `json:"payload" gorm:"type:text;not null"`
+	Status         OutboxStatus    `json:"status" gorm:"not null;default:0;index:idx_outbox_pending"`
+	Attempts       int             `json:"attempts" gorm:"not null;default:0"`
+	LastError      string          `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" gorm:"not null;index:idx_outbox_pending"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime"`
+
+	// TraceContext is the W3C trace context of the request that saved the event, in JSON
+	TraceContext string `json:"-" gorm:"type:text"`
+}
+
+// OutboxIdempotencyKey builds the idempotency key of an event, the key only needs to be unique for the event type
+func OutboxIdempotencyKey(eventType OutboxEventType, key string) string {
+	return string(eventType) + ":" + key
+}
+
+// OutboxFile is a file change carried by an outbox event
+type OutboxFile struct {
+	Path      string `json:"path"`
+	Content   []byte `json:"content"`
+	Operation string `json:"operation"`
+}
+
+// CourseRepoPayload is the payload of OutboxCreateCourseRepo
+type CourseRepoPayload struct {
+	CourseID uint64 `json:"course_id,string"`
+	UserID   uint64 `json:"user_id,string"`
+}
+
+// RevisionCreatePayload is the payload of OutboxCreateRevision
+type RevisionCreatePayload struct {
+	RevisionID uint64       `json:"revision_id,string"`
+	CourseID   uint64       `json:"course_id,string"`
+	BranchID   uint64       `json:"branch_id,string"`
+	UserID     uint64       `json:"user_id,string"`
+	Message    string       `json:"message"`
+	Files      []OutboxFile `json:"files"`
+	CourseData []byte       `json:"course_data"`
+}
+
+// CommentMirrorPayload is the payload of OutboxMirrorComment
+type CommentMirrorPayload struct {
+	RevisionID uint64 `json:"revision_id,string"`
+	CommentID  uint64 `json:"comment_id,string"`
+}
+
+// ReviewMirrorPayload is the payload of OutboxMirrorReview
+type ReviewMirrorPayload struct {
+	RevisionID uint64 `json:"revision_id,string"`
+	ReviewID   uint64 `json:"review_id,string"`
+}
@@ -2,14 +2,8 @@ package models
 
 import (
 	"time"
-
-	db "github.com/instructhub/backend/pkg/database"
 )
 
-func init() {
-	db.GetDB().AutoMigrate(&Session{})
-}
-
 // Session type / table
 type Session struct {
 	SessionID uint64    `json:"session_id,string" gorm:"primaryKey"`
@@ -18,6 +12,8 @@ type Session struct {
 	UserID    uint64    `json:"user_id,string" gorm:"not null;index"`
 	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	// AuthenticatedAt is when the user last logged in, it is kept when the session is refreshed
+	AuthenticatedAt time.Time `json:"authenticated_at" gorm:"not null"`
 
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
 }
@@ -3,15 +3,8 @@ package models
 import (
 	"strings"
 	"time"
-
-	db "github.com/instructhub/backend/pkg/database"
 )
 
-func init() {
-	db.GetDB().AutoMigrate(&User{})
-	db.GetDB().AutoMigrate(&OauthProvider{})
-}
-
 // Users data type / table
 type User struct {
 	ID          uint64    `json:"id,string" gorm:"primaryKey" binding:"required"`
@@ -21,6 +14,9 @@ type User struct {
 	Email       string    `json:"email" gorm:"unique" binding:"required,email"` // Unique
 	Password    string    `json:"password,omitempty"`                           // Hashed password, omit for OAuth users
 	Verify      bool      `json:"verify"`
+	IsAdmin     bool      `json:"is_admin" gorm:"not null;default:false"` // Set with the admin command
+	TOTPSecret  string    `json:"-"`                                      // Encrypted, set at enrollment and used once enabled
+	TOTPEnabled bool      `json:"totp_enabled" gorm:"not null;default:false"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoUpdateTime" binding:"required"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoCreateTime" binding:"required"`
 
@@ -61,6 +57,18 @@ type OauthProvider struct {
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE"`
 }
 
+// Recovery code of the two-factor authentication, usable once instead of a TOTP code
+type RecoveryCode struct {
+	ID        uint64     `json:"id,string" gorm:"primaryKey"`
+	UserID    uint64     `json:"user_id,string" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null"` // SHA-256, the codes are random enough not to need a slow hash
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	// Foreign key
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
+
 // User data for user when it need to know thier personal profile
 type UserProfile struct {
 	ID        uint64    `json:"id,string" binding:"required"`
@@ -70,4 +78,16 @@ type UserProfile struct {
 	Verify    bool      `json:"verify" `
 	CreatedAt time.Time `json:"created_at" binding:"required"`
 	UpdatedAt time.Time `json:"updated_at" binding:"required"`
+
+	// HasPassword is false for the accounts created with OAuth until they set one
+	HasPassword bool `json:"has_password"`
+	TOTPEnabled bool `json:"totp_enabled"`
+}
+
+// User data that can be shown to other users
+type UserPublicProfile struct {
+	ID          uint64  `json:"id,string"`
+	Avatar      *string `json:"avatar,omitempty"`
+	Username    string  `json:"username"`
+	DisplayName string  `json:"display_name"`
 }
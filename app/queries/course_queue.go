@@ -1,30 +1,31 @@
 package queries
 
 import (
+	"time"
+
 	"github.com/instructhub/backend/app/models"
-	db "github.com/instructhub/backend/pkg/database"
 	"gorm.io/gorm"
 )
 
 // Create new course
-func CreateNewCourse(course models.Course) *gorm.DB {
+func CreateNewCourse(tx *gorm.DB, course models.Course) *gorm.DB {
 	// Insert a new course into the database
-	result := db.GetDB().Create(&course)
+	result := tx.Create(&course)
 	return result
 }
 
 // Get course information by courseID
-func GetCourseInformation(courseID uint64) (models.Course, *gorm.DB) {
+func GetCourseInformation(db *gorm.DB, courseID uint64) (models.Course, *gorm.DB) {
 	var course models.Course
 	// Query the course based on courseID
-	result := db.GetDB().Where("id = ?", courseID).First(&course)
+	result := db.Where("id = ?", courseID).First(&course)
 	return course, result
 }
 
 // Create course image
-func CreateCourseImage(image models.CourseImage) *gorm.DB {
+func CreateCourseImage(db *gorm.DB, image models.CourseImage) *gorm.DB {
 	// Insert a new course image into the database
-	result := db.GetDB().Create(&image)
+	result := db.Create(&image)
 	return result
 }
 
@@ -37,10 +38,10 @@ func orderByPosition(db *gorm.DB) *gorm.DB {
 	return db.Order("position")
 }
 
-func GetCourseWithDetails(courseID uint64) (models.Course, *gorm.DB) {
+func GetCourseWithDetails(db *gorm.DB, courseID uint64) (models.Course, *gorm.DB) {
 	var course models.Course
 
-	result := db.GetDB().
+	result := db.
 		Preload("CourseModules", activeFilter, orderByPosition).
 		Preload("CourseModules.CourseSteps", activeFilter, orderByPosition).
 		First(&course, courseID)
@@ -49,41 +50,41 @@ func GetCourseWithDetails(courseID uint64) (models.Course, *gorm.DB) {
 }
 
 // Create course modules
-func CreateCourseModules(modules []models.CourseModule) *gorm.DB {
-	result := db.GetDB().Create(&modules)
+func CreateCourseModules(tx *gorm.DB, modules []models.CourseModule) *gorm.DB {
+	result := tx.Create(&modules)
 	return result
 }
 
 // Create course steps
-func CreateCourseSteps(steps []models.CourseStep) *gorm.DB {
-	result := db.GetDB().Create(&steps)
+func CreateCourseSteps(tx *gorm.DB, steps []models.CourseStep) *gorm.DB {
+	result := tx.Create(&steps)
 	return result
 }
 
 // Update course modules
-func UpdateCourseModule(module models.CourseModule) *gorm.DB {
-	result := db.GetDB().Model(&module).Where("id = ?", module.ID).Updates(&module)
+func UpdateCourseModule(tx *gorm.DB, module models.CourseModule) *gorm.DB {
+	result := tx.Model(&module).Where("id = ?", module.ID).Updates(&module)
 	return result
 }
 
 // Update course modules
-func UpdateCourseStep(step models.CourseStep) *gorm.DB {
-	result := db.GetDB().Model(&step).Where("id = ?", step.ID).Updates(&step)
+func UpdateCourseStep(tx *gorm.DB, step models.CourseStep) *gorm.DB {
+	result := tx.Model(&step).Where("id = ?", step.ID).Updates(&step)
 	return result
 }
 
 // Craete course revision
-func CreateNewCourseRevision(revision models.CourseRevision) *gorm.DB {
-	result := db.GetDB().Create(&revision)
+func CreateNewCourseRevision(tx *gorm.DB, revision models.CourseRevision) *gorm.DB {
+	result := tx.Create(&revision)
 	return result
 }
 
 // Get course revision with JOINs
-func GetCourseRevision(courseID uint64, revisionID uint64) (models.CourseRevision, *gorm.DB) {
+func GetCourseRevision(db *gorm.DB, courseID uint64, revisionID uint64) (models.CourseRevision, *gorm.DB) {
 	var courseRevision models.CourseRevision
 
 	// Use JOIN to fetch course revision with related course modules and course steps
-	result := db.GetDB().
+	result := db.
 		Preload("Course").
 		Preload("Course.CourseModules", activeFilter).
 		Preload("Course.CourseModules.CourseSteps", activeFilter).
@@ -94,27 +95,237 @@ func GetCourseRevision(courseID uint64, revisionID uint64) (models.CourseRevisio
 	return courseRevision, result
 }
 
+// Get course revision information by courseID and revisionID
+func GetCourseRevisionInformation(db *gorm.DB, courseID uint64, revisionID uint64) (models.CourseRevision, *gorm.DB) {
+	var courseRevision models.CourseRevision
+	result := db.
+		Where("course_id = ?", courseID).
+		Where("id = ?", revisionID).
+		First(&courseRevision)
+	return courseRevision, result
+}
+
+// Get course revision by its ID only
+func GetCourseRevisionByID(db *gorm.DB, revisionID uint64) (revision models.CourseRevision, result *gorm.DB) {
+	result = db.Where("id = ?", revisionID).First(&revision)
+	return revision, result
+}
+
+// Get course revisions of a course, newest first
+func GetCourseRevisions(db *gorm.DB, courseID uint64, status *models.RevisionStatus, offset int, limit int) (revisions []models.CourseRevision, total int64, result *gorm.DB) {
+	query := db.Model(&models.CourseRevision{}).Where("course_id = ?", courseID)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	result = query.Count(&total)
+	if result.Error != nil {
+		return revisions, total, result
+	}
+
+	result = query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&revisions)
+	return revisions, total, result
+}
+
+// Get course revision with its editor and approver
+func GetCourseRevisionWithUsers(db *gorm.DB, courseID uint64, revisionID uint64) (models.CourseRevision, *gorm.DB) {
+	var courseRevision models.CourseRevision
+
+	result := db.
+		Preload("Editor").
+		Preload("Approver").
+		Where("course_id = ?", courseID).
+		Where("id = ?", revisionID).
+		First(&courseRevision)
+
+	return courseRevision, result
+}
+
 // Update course revision
-func UpdateCourseRevision(revision models.CourseRevision) *gorm.DB {
-	result := db.GetDB().Save(&revision)
+func UpdateCourseRevision(db *gorm.DB, revision models.CourseRevision) *gorm.DB {
+	result := db.Save(&revision)
 	return result
 }
 
+// Create revision commit
+func CreateRevisionCommit(db *gorm.DB, commit models.RevisionCommit) *gorm.DB {
+	result := db.Create(&commit)
+	return result
+}
+
+// Get all commits of a revision with their authors, oldest first
+func GetRevisionCommits(db *gorm.DB, revisionID uint64) (commits []models.RevisionCommit, result *gorm.DB) {
+	result = db.
+		Preload("Author").
+		Where("revision_id = ?", revisionID).
+		Order("created_at").
+		Find(&commits)
+	return commits, result
+}
+
 // Get course landing page data
-func GetCourseLandingPage(courseID uint64) (models.CourseLandingPage, *gorm.DB) {
+func GetCourseLandingPage(db *gorm.DB, courseID uint64) (models.CourseLandingPage, *gorm.DB) {
 	var landingPage models.CourseLandingPage
-	result := db.GetDB().Where("course_id = ?", courseID).First(&landingPage)
+	result := db.Where("course_id = ?", courseID).First(&landingPage)
 	return landingPage, result
 }
 
 // Create course landing page
-func CreateCourseLandingPage(landingPage models.CourseLandingPage) *gorm.DB {
-	result := db.GetDB().Create(&landingPage)
+func CreateCourseLandingPage(db *gorm.DB, landingPage models.CourseLandingPage) *gorm.DB {
+	result := db.Create(&landingPage)
 	return result
 }
 
 // Update course revision
-func UpdateCourseLandingPage(landingPage models.CourseLandingPage) *gorm.DB {
-	result := db.GetDB().Model(&landingPage).Where("course_id = ?", landingPage.CourseID).Updates(&landingPage)
+func UpdateCourseLandingPage(db *gorm.DB, landingPage models.CourseLandingPage) *gorm.DB {
+	result := db.Model(&landingPage).Where("course_id = ?", landingPage.CourseID).Updates(&landingPage)
+	return result
+}
+
+// Mark an open revision as being merged by the approver, nothing is updated when it is not open anymore
+func StartRevisionMerge(db *gorm.DB, revisionID uint64, approverID uint64) *gorm.DB {
+	result := db.
+		Model(&models.CourseRevision{}).
+		Where("id = ?", revisionID).
+		Where("status = ?", models.RevisionOpen).
+		Updates(map[string]interface{}{
+			"status":      models.RevisionMerging,
+			"approver_id": approverID,
+		})
+	return result
+}
+
+// Point a revision to its rebased branch and pull request, nothing is updated when its status changed meanwhile
+func UpdateRevisionBranch(db *gorm.DB, revision models.CourseRevision) *gorm.DB {
+	result := db.
+		Model(&models.CourseRevision{}).
+		Where("id = ?", revision.ID).
+		Where("status = ?", revision.Status).
+		Updates(map[string]interface{}{
+			"branch_id":       revision.BranchID,
+			"pull_request_id": revision.PullRequestID,
+			"base_commit":     revision.BaseCommit,
+		})
+	return result
+}
+
+// Touch an open revision, nothing is updated when it is not open on the given branch anymore
+func TouchOpenRevision(tx *gorm.DB, revisionID uint64, branchID uint64) *gorm.DB {
+	result := tx.
+		Model(&models.CourseRevision{}).
+		Where("id = ?", revisionID).
+		Where("status = ?", models.RevisionOpen).
+		Where("branch_id = ?", branchID).
+		Update("updated_at", time.Now())
+	return result
+}
+
+// Save the status of a revision and the fields going with it, nothing is updated when it is not in the expected status anymore
+func UpdateCourseRevisionState(db *gorm.DB, revision models.CourseRevision, from models.RevisionStatus) *gorm.DB {
+	result := db.
+		Model(&models.CourseRevision{}).
+		Where("id = ?", revision.ID).
+		Where("status = ?", from).
+		Updates(map[string]interface{}{
+			"status":             revision.Status,
+			"close_reason":       revision.CloseReason,
+			"closed_by_id":       revision.ClosedByID,
+			"closed_at":          revision.ClosedAt,
+			"status_before_lock": revision.StatusBeforeLock,
+		})
+	return result
+}
+
+// Move a revision from one status to another, nothing is updated when it is not in the expected status anymore
+func UpdateCourseRevisionStatus(tx *gorm.DB, revisionID uint64, from models.RevisionStatus, to models.RevisionStatus) *gorm.DB {
+	result := tx.
+		Model(&models.CourseRevision{}).
+		Where("id = ?", revisionID).
+		Where("status = ?", from).
+		Update("status", to)
+	return result
+}
+
+// Get revisions stuck in merging since before the given time
+func GetStuckMergingRevisions(db *gorm.DB, updatedBefore time.Time, limit int) ([]models.CourseRevision, *gorm.DB) {
+	var revisions []models.CourseRevision
+
+	result := db.
+		Where("status = ?", models.RevisionMerging).
+		Where("updated_at < ?", updatedBefore).
+		Order("updated_at").
+		Limit(limit).
+		Find(&revisions)
+
+	return revisions, result
+}
+
+// Get closed revisions whose branch is still kept after the given time
+func GetClosedRevisionsBefore(db *gorm.DB, closedBefore time.Time, limit int) ([]models.CourseRevision, *gorm.DB) {
+	var revisions []models.CourseRevision
+
+	result := db.
+		Where("status = ?", models.RevisionClose).
+		Where("branch_deleted = ?", false).
+		Where("closed_at < ?", closedBefore).
+		Order("closed_at").
+		Limit(limit).
+		Find(&revisions)
+
+	return revisions, result
+}
+
+// Mark the branch of a revision as deleted
+func MarkRevisionBranchDeleted(db *gorm.DB, revisionID uint64) *gorm.DB {
+	result := db.
+		Model(&models.CourseRevision{}).
+		Where("id = ?", revisionID).
+		Update("branch_deleted", true)
+	return result
+}
+
+// Get a page of course IDs ordered by ID, starting after the given ID
+func GetCourseIDsAfter(db *gorm.DB, afterID uint64, limit int) (courseIDs []uint64, result *gorm.DB) {
+	result = db.
+		Model(&models.Course{}).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &courseIDs)
+	return courseIDs, result
+}
+
+// Get every module and step of a course, including the inactive ones
+func GetAllCourseModules(db *gorm.DB, courseID uint64) (modules []models.CourseModule, result *gorm.DB) {
+	result = db.
+		Preload("CourseSteps", orderByPosition).
+		Where("course_id = ?", courseID).
+		Order("position").
+		Find(&modules)
+	return modules, result
+}
+
+// Save course modules, creating the ones that don't exist
+func SaveCourseModules(tx *gorm.DB, modules []models.CourseModule) *gorm.DB {
+	result := tx.Omit("CourseSteps").Save(&modules)
 	return result
 }
+
+// Save course steps, creating the ones that don't exist
+func SaveCourseSteps(tx *gorm.DB, steps []models.CourseStep) *gorm.DB {
+	result := tx.Save(&steps)
+	return result
+}
+
+// Get the revisions of a course with the given status
+func GetCourseRevisionsByStatus(db *gorm.DB, courseID uint64, status models.RevisionStatus) (revisions []models.CourseRevision, result *gorm.DB) {
+	result = db.
+		Where("course_id = ?", courseID).
+		Where("status = ?", status).
+		Find(&revisions)
+	return revisions, result
+}
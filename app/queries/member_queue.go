@@ -0,0 +1,74 @@
+package queries
+
+import (
+	"github.com/instructhub/backend/app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Create course member
+func CreateCourseMember(tx *gorm.DB, member models.CourseMember) *gorm.DB {
+	result := tx.Create(&member)
+	return result
+}
+
+// Get course member by courseID and userID
+func GetCourseMember(db *gorm.DB, courseID uint64, userID uint64) (member models.CourseMember, result *gorm.DB) {
+	result = db.
+		Where("course_id = ?", courseID).
+		Where("user_id = ?", userID).
+		First(&member)
+	return member, result
+}
+
+// Get all members of a course with their user data
+func GetCourseMembers(db *gorm.DB, courseID uint64) (members []models.CourseMember, result *gorm.DB) {
+	result = db.
+		Preload("User").
+		Where("course_id = ?", courseID).
+		Order("role DESC, created_at").
+		Find(&members)
+	return members, result
+}
+
+// Count course members with the given role
+func CountCourseMembersByRole(db *gorm.DB, courseID uint64, role models.CourseRole) (count int64, result *gorm.DB) {
+	result = db.
+		Model(&models.CourseMember{}).
+		Where("course_id = ?", courseID).
+		Where("role = ?", role).
+		Count(&count)
+	return count, result
+}
+
+// Get the user IDs of the course members with the given role, locking their rows until the transaction ends
+func LockCourseMembersByRole(tx *gorm.DB, courseID uint64, role models.CourseRole) (userIDs []uint64, result *gorm.DB) {
+	result = tx.
+		Model(&models.CourseMember{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("course_id = ?", courseID).
+		Where("role = ?", role).
+		Pluck("user_id", &userIDs)
+	return userIDs, result
+}
+
+// Update course member role
+func UpdateCourseMemberRole(db *gorm.DB, courseID uint64, userID uint64, role models.CourseRole) *gorm.DB {
+	result := db.
+		Model(&models.CourseMember{}).
+		Where("course_id = ?", courseID).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"role": role,
+		})
+	return result
+}
+
+// Delete course member
+func DeleteCourseMember(db *gorm.DB, courseID uint64, userID uint64) *gorm.DB {
+	result := db.
+		Where("course_id = ?", courseID).
+		Where("user_id = ?", userID).
+		Delete(&models.CourseMember{})
+	return result
+}
@@ -0,0 +1,45 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/instructhub/backend/app/models"
+	"gorm.io/gorm"
+)
+
+// Save the TOTP secret of a user and whether it is enabled
+func UpdateUserTOTP(db *gorm.DB, userID uint64, encryptedSecret string, enabled bool) *gorm.DB {
+	result := db.
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"totp_secret":  encryptedSecret,
+			"totp_enabled": enabled,
+		})
+	return result
+}
+
+// Replace the recovery codes of a user
+func ReplaceRecoveryCodes(db *gorm.DB, userID uint64, codes []models.RecoveryCode) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := DeleteUserRecoveryCodes(tx, userID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Delete every recovery code of a user
+func DeleteUserRecoveryCodes(db *gorm.DB, userID uint64) *gorm.DB {
+	result := db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{})
+	return result
+}
+
+// Mark an unused recovery code as used, RowsAffected is 0 when the code is unknown or already used
+func UseRecoveryCode(db *gorm.DB, userID uint64, codeHash string, usedAt time.Time) *gorm.DB {
+	result := db.
+		Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	return result
+}
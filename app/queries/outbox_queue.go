@@ -0,0 +1,84 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/instructhub/backend/app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Create outbox event, an event with the same idempotency key is only saved once
+func CreateOutboxEvent(tx *gorm.DB, event models.OutboxEvent) *gorm.DB {
+	result := tx.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "idempotency_key"}}, DoNothing: true}).
+		Create(&event)
+	return result
+}
+
+// Claim the pending outbox events that are due, they are hidden from other workers for the lease duration
+func ClaimOutboxEvents(db *gorm.DB, limit int, lease time.Duration) (events []models.OutboxEvent, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.OutboxPending).
+			Where("next_attempt_at <= ?", now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&events)
+		if result.Error != nil || len(events) == 0 {
+			return result.Error
+		}
+
+		ids := make([]uint64, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return tx.
+			Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).
+			Error
+	})
+	return events, err
+}
+
+// Check if the outbox event with the given idempotency key is still pending
+func IsOutboxEventPending(db *gorm.DB, idempotencyKey string) (bool, *gorm.DB) {
+	var count int64
+
+	result := db.
+		Model(&models.OutboxEvent{}).
+		Where("idempotency_key = ?", idempotencyKey).
+		Where("status = ?", models.OutboxPending).
+		Count(&count)
+
+	return count > 0, result
+}
+
+// Mark outbox event as done
+func CompleteOutboxEvent(db *gorm.DB, eventID uint64) *gorm.DB {
+	result := db.
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", eventID).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxDone,
+			"last_error": "",
+		})
+	return result
+}
+
+// Record a failed attempt of an outbox event and when to try again
+func FailOutboxEvent(db *gorm.DB, eventID uint64, attempts int, status models.OutboxStatus, nextAttemptAt time.Time, lastError string) *gorm.DB {
+	result := db.
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", eventID).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		})
+	return result
+}
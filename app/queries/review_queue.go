@@ -0,0 +1,78 @@
+package queries
+
+import (
+	"github.com/instructhub/backend/app/models"
+	"gorm.io/gorm"
+)
+
+// Create revision comment
+func CreateRevisionComment(tx *gorm.DB, comment models.RevisionComment) *gorm.DB {
+	result := tx.Create(&comment)
+	return result
+}
+
+// Get revision comment by revisionID and commentID
+func GetRevisionComment(db *gorm.DB, revisionID uint64, commentID uint64) (comment models.RevisionComment, result *gorm.DB) {
+	result = db.
+		Where("revision_id = ?", revisionID).
+		Where("id = ?", commentID).
+		First(&comment)
+	return comment, result
+}
+
+// Get all comments of a revision with their authors, oldest first
+func GetRevisionComments(db *gorm.DB, revisionID uint64) (comments []models.RevisionComment, result *gorm.DB) {
+	result = db.
+		Preload("Author").
+		Where("revision_id = ?", revisionID).
+		Order("created_at").
+		Find(&comments)
+	return comments, result
+}
+
+// Update the id of the comment mirrored on the gitea pull request
+func UpdateRevisionCommentGiteaID(db *gorm.DB, commentID uint64, giteaCommentID int64) *gorm.DB {
+	result := db.
+		Model(&models.RevisionComment{}).
+		Where("id = ?", commentID).
+		Updates(map[string]interface{}{
+			"gitea_comment_id": giteaCommentID,
+		})
+	return result
+}
+
+// Create revision review
+func CreateRevisionReview(tx *gorm.DB, review models.RevisionReview) *gorm.DB {
+	result := tx.Create(&review)
+	return result
+}
+
+// Get revision review by revisionID and reviewID
+func GetRevisionReview(db *gorm.DB, revisionID uint64, reviewID uint64) (review models.RevisionReview, result *gorm.DB) {
+	result = db.
+		Where("revision_id = ?", revisionID).
+		Where("id = ?", reviewID).
+		First(&review)
+	return review, result
+}
+
+// Get all reviews of a revision with their reviewers, oldest first
+func GetRevisionReviews(db *gorm.DB, revisionID uint64) (reviews []models.RevisionReview, result *gorm.DB) {
+	result = db.
+		Preload("Reviewer").
+		Where("revision_id = ?", revisionID).
+		Order("created_at").
+		Find(&reviews)
+	return reviews, result
+}
+
+// Update the id of the review mirrored on the gitea pull request
+func UpdateRevisionReviewGiteaID(db *gorm.DB, reviewID uint64, giteaReviewID int64) *gorm.DB {
+	result := db.
+		Model(&models.RevisionReview{}).
+		Where("id = ?", reviewID).
+		Updates(map[string]interface{}{
+			"gitea_review_id": giteaReviewID,
+		})
+	return result
+}
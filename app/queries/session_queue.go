@@ -1,29 +1,48 @@
 package queries
 
 import (
+	"time"
+
 	"github.com/instructhub/backend/app/models"
-	db "github.com/instructhub/backend/pkg/database"
 	"gorm.io/gorm"
 )
 
 // Create new session
-func CreateSessionQueue(session models.Session) *gorm.DB {
+func CreateSessionQueue(db *gorm.DB, session models.Session) *gorm.DB {
 	// Create a new session record in the database
-	result := db.GetDB().Create(&session)
+	result := db.Create(&session)
 	return result
 }
 
 // Get session by secretKey
-func GetSessionQueueBySecretKey(secretKey string) (models.Session, *gorm.DB) {
+func GetSessionQueueBySecretKey(db *gorm.DB, secretKey string) (models.Session, *gorm.DB) {
 	var session models.Session
 	// Query the session by secretKey
-	result := db.GetDB().Where("secret_key = ?", secretKey).First(&session)
+	result := db.Where("secret_key = ?", secretKey).First(&session)
 	return session, result
 }
 
 // Delete session by secretKey
-func DeleteSessionQueue(secretKey string) *gorm.DB {
+func DeleteSessionQueue(db *gorm.DB, secretKey string) *gorm.DB {
 	// Delete session by secretKey
-	result := db.GetDB().Where("secret_key = ?", secretKey).Delete(&models.Session{})
+	result := db.Where("secret_key = ?", secretKey).Delete(&models.Session{})
+	return result
+}
+
+// Delete every session of a user
+func DeleteUserSessions(db *gorm.DB, userID uint64) *gorm.DB {
+	result := db.Where("user_id = ?", userID).Delete(&models.Session{})
+	return result
+}
+
+// Delete the sessions expired before a time
+func DeleteExpiredSessions(db *gorm.DB, before time.Time) *gorm.DB {
+	result := db.Where("expires_at < ?", before).Delete(&models.Session{})
+	return result
+}
+
+// Delete every session of a user but the one of secretKey
+func DeleteOtherUserSessions(db *gorm.DB, userID uint64, secretKey string) *gorm.DB {
+	result := db.Where("user_id = ? AND secret_key <> ?", userID, secretKey).Delete(&models.Session{})
 	return result
 }
@@ -2,39 +2,38 @@ package queries
 
 import (
 	"github.com/instructhub/backend/app/models"
-	db "github.com/instructhub/backend/pkg/database"
 	"gorm.io/gorm"
 )
 
 // Get user by email
-func GetUserQueueByEmail(email string) (user models.User,result *gorm.DB) {
-	result = db.GetDB().Where("email = ?", email).First(&user)
+func GetUserQueueByEmail(db *gorm.DB, email string) (user models.User,result *gorm.DB) {
+	result = db.Where("email = ?", email).First(&user)
 	return user, result
 }
 
 // Get user by username
-func GetUserQueueByUsername(username string) (user models.User,result *gorm.DB) {
+func GetUserQueueByUsername(db *gorm.DB, username string) (user models.User,result *gorm.DB) {
 	// Use Where to filter by username
-	result = db.GetDB().Where("username = ?", username).First(&user)
+	result = db.Where("username = ?", username).First(&user)
 	return user, result
 }
 
 // Get user by user ID
-func GetUserQueueByID(id uint64) (user models.User,result *gorm.DB) {
-	result = db.GetDB().Where("id = ?", id).First(&user)
+func GetUserQueueByID(db *gorm.DB, id uint64) (user models.User,result *gorm.DB) {
+	result = db.Where("id = ?", id).First(&user)
 	return user, result
 }
 
 // Create new user data
-func CreateUserQueue(user models.User) *gorm.DB {
-	result := db.GetDB().Create(&user)
+func CreateUserQueue(db *gorm.DB, user models.User) *gorm.DB {
+	result := db.Create(&user)
 	return result
 }
 
-func UpdateUserVerifyStatus(userID uint64, verify bool) *gorm.DB {
+func UpdateUserVerifyStatus(db *gorm.DB, userID uint64, verify bool) *gorm.DB {
 	// Update only specific fields (e.g., email) where id matches
 	var user models.User
-	result := db.GetDB().
+	result := db.
 		Model(&user).
 		Where("id = ?", userID).
 		Updates(map[string]interface{}{
@@ -45,18 +44,36 @@ func UpdateUserVerifyStatus(userID uint64, verify bool) *gorm.DB {
 }
 
 // Update user third-part oauth provider data
-func AddUserProvider(oauthProvider models.OauthProvider) *gorm.DB {
-	result := db.GetDB().Create(&oauthProvider)
+func AddUserProvider(db *gorm.DB, oauthProvider models.OauthProvider) *gorm.DB {
+	result := db.Create(&oauthProvider)
 	return result
 }
 
 // Get user and associated provider data
-func GetUserAndProvider(userEmail string) (user models.User, result *gorm.DB) {
+func GetUserAndProvider(db *gorm.DB, userEmail string) (user models.User, result *gorm.DB) {
 	// Preload the associated OAuth provider(s)
 	user.Email = userEmail
-	result = db.GetDB().
+	result = db.
 		Preload("OauthProviders").
 		Where("email = ?", userEmail).
 		First(&user)
 	return user, result
 }
+
+// Grant or remove the admin rights of a user
+func UpdateUserAdminStatus(db *gorm.DB, userID uint64, isAdmin bool) *gorm.DB {
+	result := db.
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("is_admin", isAdmin)
+	return result
+}
+
+// Replace the password hash of a user
+func UpdateUserPassword(db *gorm.DB, userID uint64, hashedPassword string) *gorm.DB {
+	result := db.
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("password", hashedPassword)
+	return result
+}
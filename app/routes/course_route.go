@@ -2,27 +2,51 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app"
 	courses "github.com/instructhub/backend/app/controllers/course"
+	"github.com/instructhub/backend/app/models"
 	"github.com/instructhub/backend/pkg/middleware"
 )
 
-func CourseRoute(r *gin.RouterGroup) {
+func CourseRoute(r *gin.RouterGroup, app *app.App) {
+	h := courses.NewHandler(app)
 	g := r.Group("/courses")
 
 	// Get course public data
-	g.GET("/:courseID", courses.GetCourse)
-	g.GET("/:courseID/:stepID", courses.GetStepContent)
-	g.GET("/landing/:courseID", courses.GetCourseLandingPageData)
+	g.GET("/:courseID", h.GetCourse)
+	g.GET("/:courseID/:stepID", h.GetStepContent)
+	g.GET("/landing/:courseID", h.GetCourseLandingPageData)
 
 	g.Use(middleware.IsAuthorized())
 	// Course information
-	g.POST("/new", courses.CreateNewCourse)
-	g.POST("/landing/:courseID", courses.UpdateCourseLandingPage)
+	g.POST("/new", h.CreateNewCourse)
+	g.POST("/landing/:courseID", middleware.RequireCourseRole(app.DB, models.CourseMaintainer), h.UpdateCourseLandingPage)
 
 	// Revision
-	g.POST("/revision/:courseID", courses.CreateNewRevision)
-	g.POST("/revision/:courseID/:revisionID/approve", courses.ApproveRevision)
+	g.GET("/revision/:courseID", middleware.RequireCourseAccess(app.DB), h.ListRevisions)
+	g.GET("/revision/:courseID/:revisionID", middleware.RequireCourseAccess(app.DB), h.GetRevision)
+	g.GET("/revision/:courseID/:revisionID/diff", middleware.RequireCourseAccess(app.DB), h.GetRevisionDiff)
+	g.POST("/revision/:courseID", middleware.RequireCourseAccess(app.DB), h.CreateNewRevision)
+	g.POST("/revision/:courseID/:revisionID/commits", middleware.RequireCourseAccess(app.DB), h.UpdateRevision)
+	g.POST("/revision/:courseID/:revisionID/rebase", middleware.RequireCourseAccess(app.DB), h.RebaseRevision)
+	g.GET("/revision/:courseID/:revisionID/comments", middleware.RequireCourseAccess(app.DB), h.ListRevisionComments)
+	g.POST("/revision/:courseID/:revisionID/comments", middleware.RequireCourseAccess(app.DB), h.CreateRevisionComment)
+	g.GET("/revision/:courseID/:revisionID/reviews", middleware.RequireCourseAccess(app.DB), h.ListRevisionReviews)
+	g.POST("/revision/:courseID/:revisionID/reviews", middleware.RequireCourseRole(app.DB, models.CourseReviewer), h.CreateRevisionReview)
+	g.POST("/revision/:courseID/:revisionID/approve", middleware.RequireCourseRole(app.DB, models.CourseMaintainer), h.ApproveRevision)
+	g.POST("/revision/:courseID/:revisionID/close", middleware.RequireCourseAccess(app.DB), h.CloseRevision)
+	g.POST("/revision/:courseID/:revisionID/reopen", middleware.RequireCourseAccess(app.DB), h.ReopenRevision)
+	g.POST("/revision/:courseID/:revisionID/lock", middleware.RequireCourseRole(app.DB, models.CourseMaintainer), h.LockRevision)
+	g.POST("/revision/:courseID/:revisionID/unlock", middleware.RequireCourseRole(app.DB, models.CourseMaintainer), h.UnlockRevision)
+
+	// Members
+	g.GET("/:courseID/members", middleware.RequireCourseAccess(app.DB), h.ListCourseMembers)
+	g.POST("/:courseID/members/invite", middleware.RequireCourseRole(app.DB, models.CourseMaintainer), h.InviteCourseMember)
+	g.POST("/:courseID/members/invitations/:inviteKey/accept", h.AcceptCourseInvitation)
+	g.POST("/:courseID/members/invitations/:inviteKey/decline", h.DeclineCourseInvitation)
+	g.PATCH("/:courseID/members/:userID", middleware.RequireCourseRole(app.DB, models.CourseMaintainer), h.UpdateCourseMemberRole)
+	g.DELETE("/:courseID/members/:userID", middleware.RequireCourseRole(app.DB, models.CourseContributor), h.RemoveCourseMember)
 
 	// Image upload
-	g.POST("/:courseID/image/upload", courses.UploadImage)
+	g.POST("/:courseID/image/upload", middleware.RateLimit(app.RateLimiter, imageUploadRateLimits...), middleware.RequireCourseRole(app.DB, models.CourseContributor), h.UploadImage)
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/controllers/health"
+	"github.com/instructhub/backend/pkg/middleware"
+)
+
+// HealthRoute serves the probes of the load balancer at the root, outside of the API version
+func HealthRoute(r gin.IRoutes, app *app.App) {
+	h := health.NewHandler(app)
+
+	r.GET("/healthz", h.Liveness)
+	r.GET("/readyz", h.Readiness)
+}
+
+// StatusRoute serves the status of the instance to the admins
+func StatusRoute(r *gin.RouterGroup, app *app.App) {
+	h := health.NewHandler(app)
+
+	r.GET("/status", middleware.IsAuthorized(), middleware.RequireAdmin(app.DB), h.Status)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/pkg/metrics"
+	"github.com/instructhub/backend/pkg/middleware"
+)
+
+// MetricsRoute serves the Prometheus metrics at the root, outside of the API version
+func MetricsRoute(r gin.IRoutes, app *app.App) {
+	handlers := []gin.HandlerFunc{gin.WrapH(metrics.Handler())}
+	if app.Config.Server.MetricsToken != "" {
+		handlers = append([]gin.HandlerFunc{middleware.RequireBearerToken(app.Config.Server.MetricsToken)}, handlers...)
+	}
+	r.GET("/metrics", handlers...)
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/instructhub/backend/pkg/middleware"
+	"github.com/instructhub/backend/pkg/ratelimit"
+)
+
+// The policies of the rate limited routes, a request is rejected once one of its rules is exceeded
+var (
+	loginRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "login_ip", Limit: 20, Period: time.Minute}, Key: middleware.ByIP},
+		{Policy: ratelimit.Policy{Name: "login_email", Limit: 10, Period: 15 * time.Minute}, Key: middleware.ByEmail},
+	}
+	signupRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "signup_ip", Limit: 5, Period: time.Hour}, Key: middleware.ByIP},
+		{Policy: ratelimit.Policy{Name: "signup_email", Limit: 3, Period: time.Hour}, Key: middleware.ByEmail},
+	}
+	// One verification email a minute
+	resendVerificationRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "resend_verification_ip", Limit: 10, Period: time.Hour}, Key: middleware.ByIP},
+		{Policy: ratelimit.Policy{Name: "resend_verification_user", Limit: 1, Period: time.Minute}, Key: middleware.ByUserID},
+	}
+	// One reset email a minute for an address
+	passwordResetRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "password_reset_ip", Limit: 10, Period: time.Hour}, Key: middleware.ByIP},
+		{Policy: ratelimit.Policy{Name: "password_reset_email", Limit: 1, Period: time.Minute}, Key: middleware.ByEmail},
+	}
+	confirmPasswordResetRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "password_reset_confirm_ip", Limit: 20, Period: time.Hour}, Key: middleware.ByIP},
+	}
+	// The current password can't be guessed with a stolen session
+	changePasswordRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "change_password_user", Limit: 5, Period: 15 * time.Minute}, Key: middleware.ByUserID},
+	}
+	// A 6 digit code can't be guessed before the pending login expires
+	loginMFARateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "login_mfa_ip", Limit: 20, Period: time.Minute}, Key: middleware.ByIP},
+		{Policy: ratelimit.Policy{Name: "login_mfa_user", Limit: 5, Period: 5 * time.Minute}, Key: middleware.ByUserID},
+	}
+	mfaCodeRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "mfa_code_user", Limit: 5, Period: 5 * time.Minute}, Key: middleware.ByUserID},
+	}
+	imageUploadRateLimits = []middleware.RateLimitRule{
+		{Policy: ratelimit.Policy{Name: "image_upload_user", Limit: 30, Period: time.Minute}, Key: middleware.ByUserID},
+	}
+)
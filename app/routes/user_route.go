@@ -2,16 +2,27 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app"
 	"github.com/instructhub/backend/app/controllers"
 	"github.com/instructhub/backend/pkg/middleware"
 )
 
-func UserRoute(r *gin.RouterGroup) {
+func UserRoute(r *gin.RouterGroup, app *app.App) {
+	h := controllers.NewHandler(app)
 	user := r.Group("/users")
 	user.Use(middleware.IsAuthorized())
 
 	// Cheeck for login or not
-	user.GET("/login/check", controllers.CheckLogin) 
+	user.GET("/login/check", controllers.CheckLogin)
 	// Get user personal profile
-	user.GET("/personal/profile", controllers.GetProfile)
+	user.GET("/personal/profile", h.GetProfile)
+	// Password of the user, set is for the accounts created with OAuth
+	user.POST("/password/change", middleware.RateLimit(app.RateLimiter, changePasswordRateLimits...), h.ChangePassword)
+	user.POST("/password/set", h.SetPassword)
+	// Two-factor authentication
+	user.POST("/mfa/totp/enroll", h.EnrollTOTP)
+	user.POST("/mfa/totp/confirm", middleware.RateLimit(app.RateLimiter, mfaCodeRateLimits...), h.ConfirmTOTP)
+	user.POST("/mfa/totp/disable", middleware.RateLimit(app.RateLimiter, mfaCodeRateLimits...), h.DisableTOTP)
+	// Lift the lock of an account after too many failed logins
+	user.POST("/:userID/unlock", middleware.RequireAdmin(app.DB), h.UnlockUser)
 }
@@ -0,0 +1,67 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/content"
+	"github.com/instructhub/backend/pkg/tracing"
+	"github.com/instructhub/backend/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// branchCleanupBatchSize is how many revisions are cleaned up in a single run
+const branchCleanupBatchSize = 100
+
+// StartBranchCleanup deletes the branches of revisions closed for longer than the grace period until ctx is done
+func StartBranchCleanup(ctx context.Context, app *app.App, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cleanupClosedBranches(app)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanupClosedBranches deletes the branches of one batch of expired closed revisions
+func cleanupClosedBranches(app *app.App) {
+	revisions, result := queries.GetClosedRevisionsBefore(app.DB, time.Now().Add(-app.Config.Gitea.RevisionBranchGracePeriod), branchCleanupBatchSize)
+	if result.Error != nil {
+		app.Logger.Error("Error fetching closed revisions", zap.Error(result.Error))
+		return
+	}
+
+	for _, revision := range revisions {
+		ctx, span := tracer.Start(context.Background(), "workers.delete_revision_branch",
+			trace.WithAttributes(attribute.String("revision.id", utils.Uint64ToStr(revision.ID))))
+		err := deleteRevisionBranch(ctx, app.Content, revision)
+		if err != nil {
+			app.Logger.Error("Error deleting revision branch", zap.Uint64("revision_id", revision.ID), zap.Error(err))
+		} else if result := queries.MarkRevisionBranchDeleted(app.DB.WithContext(ctx), revision.ID); result.Error != nil {
+			err = result.Error
+			app.Logger.Error("Error marking revision branch deleted", zap.Uint64("revision_id", revision.ID), zap.Error(result.Error))
+		}
+		tracing.End(span, err)
+	}
+}
+
+// deleteRevisionBranch deletes the branch of the revision, a missing branch counts as deleted
+func deleteRevisionBranch(ctx context.Context, store content.ContentStore, revision models.CourseRevision) error {
+	err := store.DeleteBranch(ctx, utils.Uint64ToStr(revision.CourseID), utils.Uint64ToStr(revision.BranchID))
+	if errors.Is(err, content.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/consistency"
+	"github.com/instructhub/backend/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+// StartConsistencyCheck reports the differences between the database and Gitea until ctx is done, nothing is repaired
+func StartConsistencyCheck(ctx context.Context, app *app.App, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reportConsistency(app)
+	}
+}
+
+// reportConsistency runs one check and logs every issue found
+func reportConsistency(app *app.App) {
+	ctx, span := tracer.Start(context.Background(), "workers.consistency_check")
+	report, err := consistency.NewChecker(app.DB, app.Content).Run(ctx, consistency.RepairOptions{})
+	tracing.End(span, err)
+	if err != nil {
+		app.Logger.Error("Error checking consistency", zap.Error(err))
+		return
+	}
+
+	for _, issue := range report.Issues {
+		app.Logger.Warn("Consistency issue",
+			zap.String("kind", string(issue.Kind)),
+			zap.Uint64("course_id", issue.CourseID),
+			zap.String("target", issue.Target),
+			zap.String("message", issue.Message),
+		)
+	}
+	app.Logger.Info("Consistency check finished",
+		zap.Int("checked_courses", report.CheckedCourses),
+		zap.Int("issues", len(report.Issues)),
+		zap.Duration("duration", report.FinishedAt.Sub(report.StartedAt)),
+	)
+}
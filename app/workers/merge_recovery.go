@@ -0,0 +1,56 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/tracing"
+	"github.com/instructhub/backend/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// mergeRecoveryDelay is how long a revision stays merging before it is considered abandoned
+	mergeRecoveryDelay     = 5 * time.Minute
+	mergeRecoveryBatchSize = 50
+)
+
+// StartMergeRecovery re-drives the approvals left merging by a crash or a failed request until ctx is done
+func StartMergeRecovery(ctx context.Context, app *app.App, interval time.Duration, resume func(ctx context.Context, revision models.CourseRevision) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		recoverMergingRevisions(app, resume)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recoverMergingRevisions resumes one batch of revisions stuck in merging
+func recoverMergingRevisions(app *app.App, resume func(ctx context.Context, revision models.CourseRevision) error) {
+	revisions, result := queries.GetStuckMergingRevisions(app.DB, time.Now().Add(-mergeRecoveryDelay), mergeRecoveryBatchSize)
+	if result.Error != nil {
+		app.Logger.Error("Error fetching merging revisions", zap.Error(result.Error))
+		return
+	}
+
+	for _, revision := range revisions {
+		ctx, span := tracer.Start(context.Background(), "workers.resume_revision_approval",
+			trace.WithAttributes(attribute.String("revision.id", utils.Uint64ToStr(revision.ID))))
+		err := resume(ctx, revision)
+		tracing.End(span, err)
+		if err != nil {
+			app.Logger.Error("Error resuming revision approval", zap.Uint64("revision_id", revision.ID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/tracing"
+	"github.com/instructhub/backend/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var tracer = tracing.Tracer("github.com/instructhub/backend/app/workers")
+
+const (
+	outboxBatchSize   = 20
+	outboxMaxAttempts = 10
+	// outboxLease is how long a claimed event is hidden from the other workers
+	outboxLease      = 5 * time.Minute
+	outboxBaseDelay  = 10 * time.Second
+	outboxMaxBackoff = time.Hour
+)
+
+// OutboxHandler performs the side effect of an outbox event, it must be safe to run more than once.
+// ctx carries the trace of the request that saved the event
+type OutboxHandler func(ctx context.Context, event models.OutboxEvent) error
+
+// outboxWakeup wakes the outbox worker up before its next tick
+var outboxWakeup = make(chan struct{}, 1)
+
+// NotifyOutbox tells the outbox worker new events were saved
+func NotifyOutbox() {
+	select {
+	case outboxWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// StartOutbox performs the pending outbox events with their handler until ctx is done
+func StartOutbox(ctx context.Context, app *app.App, interval time.Duration, handlers map[models.OutboxEventType]OutboxHandler) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches are claimed, unless shutting down
+		for ctx.Err() == nil {
+			if processOutboxEvents(app, handlers) < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-outboxWakeup:
+		}
+	}
+}
+
+// processOutboxEvents claims and performs one batch of events, it returns how many were claimed
+func processOutboxEvents(app *app.App, handlers map[models.OutboxEventType]OutboxHandler) int {
+	events, err := queries.ClaimOutboxEvents(app.DB, outboxBatchSize, outboxLease)
+	if err != nil {
+		app.Logger.Error("Error claiming outbox events", zap.Error(err))
+		return 0
+	}
+
+	for _, event := range events {
+		ctx, span := startOutboxSpan(event)
+		handler, ok := handlers[event.Type]
+		if !ok {
+			err = fmt.Errorf("no handler for outbox event type %s", event.Type)
+		} else {
+			err = handler(ctx, event)
+		}
+		tracing.End(span, err)
+
+		if err == nil {
+			if result := queries.CompleteOutboxEvent(app.DB, event.ID); result.Error != nil {
+				app.Logger.Error("Error completing outbox event", zap.Uint64("event_id", event.ID), zap.Error(result.Error))
+			}
+			continue
+		}
+
+		attempts := event.Attempts + 1
+		status := models.OutboxPending
+		if attempts >= outboxMaxAttempts {
+			status = models.OutboxFailed
+		}
+		app.Logger.Error("Error performing outbox event",
+			zap.Uint64("event_id", event.ID),
+			zap.String("type", string(event.Type)),
+			zap.Int("attempts", attempts),
+			zap.Error(err),
+		)
+		if result := queries.FailOutboxEvent(app.DB, event.ID, attempts, status, time.Now().Add(outboxBackoff(attempts)), err.Error()); result.Error != nil {
+			app.Logger.Error("Error saving outbox event failure", zap.Uint64("event_id", event.ID), zap.Error(result.Error))
+		}
+	}
+
+	return len(events)
+}
+
+// startOutboxSpan starts the span of an event, it continues the trace of the request that saved the event
+func startOutboxSpan(event models.OutboxEvent) (context.Context, trace.Span) {
+	ctx := context.Background()
+	carrier := map[string]string{}
+	if event.TraceContext != "" && json.Unmarshal([]byte(event.TraceContext), &carrier) == nil {
+		ctx = tracing.Extract(ctx, carrier)
+	}
+	return tracer.Start(ctx, "outbox."+string(event.Type), trace.WithAttributes(
+		attribute.String("outbox.event_id", utils.Uint64ToStr(event.ID)),
+		attribute.Int("outbox.attempt", event.Attempts+1),
+	))
+}
+
+// outboxBackoff doubles the delay after every failed attempt
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxBackoff {
+		delay = outboxMaxBackoff
+	}
+	return delay
+}
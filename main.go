@@ -1,34 +1,84 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app"
+	"github.com/instructhub/backend/app/commands"
+	courses "github.com/instructhub/backend/app/controllers/course"
 	"github.com/instructhub/backend/app/routes"
-	_ "github.com/instructhub/backend/pkg/cache"
-	_ "github.com/instructhub/backend/pkg/database"
-	"github.com/instructhub/backend/pkg/logger"
+	"github.com/instructhub/backend/app/workers"
+	"github.com/instructhub/backend/pkg/config"
 	"github.com/instructhub/backend/pkg/middleware"
-	_ "github.com/instructhub/backend/pkg/oauth"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/instructhub/backend/pkg/storage"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 )
 
 func main() {
+	// Run a command instead of the server
+	if len(os.Args) > 1 {
+		if err := commands.Run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	gin.SetMode(cfg.Server.GinMode)
+
+	app, err := app.New(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	root := gin.New()
+	// c is given as the context of the queries and the calls, it carries the span of the request
+	root.ContextWithFallback = true
 
 	root.SetTrustedProxies([]string{"127.0.0.1"})
+	// Registered before the logger so the probes and the scrapes don't flood the logs
+	routes.HealthRoute(root, app)
+	routes.MetricsRoute(root, app)
 	root.StaticFile("/favicon.ico", "./static/favicon.ico")
-	root.Use(middleware.CustomLogger())
-	root.Use(middleware.ErrorLoggerMiddleware())
+	root.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	root.Use(middleware.Metrics())
+	root.Use(middleware.CustomLogger(app.Logger))
+	root.Use(middleware.ErrorLoggerMiddleware(app.Logger))
 	root.LoadHTMLGlob("template/*")
-	// Init all dependencies
+	r := root.Group("/api/v" + cfg.Server.Version)
+
+	route(r, app)
 
-	r := root.Group("/api/v" + os.Getenv("VERSION"))
+	// Files of the local blob store are served by the API itself
+	if cfg.Storage.Type == "local" {
+		r.Static(storage.LocalRoute, cfg.Storage.Path)
+	}
 
-	route(r)
+	printAppInfo(app.Logger, cfg)
 
-	printAppInfo()
+	// Background jobs, they keep running while the requests are drained since the requests queue jobs
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workersGroup sync.WaitGroup
+	courseHandler := courses.NewHandler(app)
+	startWorker(&workersGroup, func() { workers.StartBranchCleanup(workersCtx, app, time.Hour) })
+	startWorker(&workersGroup, func() { workers.StartMergeRecovery(workersCtx, app, time.Minute, courseHandler.ResumeRevisionApproval) })
+	startWorker(&workersGroup, func() { workers.StartOutbox(workersCtx, app, 10*time.Second, courseHandler.OutboxHandlers()) })
+	startWorker(&workersGroup, func() { workers.StartConsistencyCheck(workersCtx, app, cfg.Gitea.ConsistencyCheckInterval) })
 
 	root.NoRoute(func(c *gin.Context) {
 		c.JSON(404, gin.H{
@@ -37,23 +87,85 @@ func main() {
 		})
 	})
 
-	if err := root.Run(); err != nil {
-		logger.Log.Sugar().Fatal("Server failed to start: %v", err)
+	// Shut down on Ctrl-C or when the deployment stops the container
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           root,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	app.Logger.Info("Listening on " + server.Addr)
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		app.Logger.Error("Server failed", zap.Error(err))
+		exitCode = 1
+	case <-signals.Done():
+		app.Logger.Info("Shutting down")
+	}
+	// A second signal kills the process
+	stopSignals()
+
+	shutdown(app, server, stopWorkers, &workersGroup)
+	app.Close()
+	os.Exit(exitCode)
+}
+
+// startWorker runs a background job the shutdown waits for
+func startWorker(group *sync.WaitGroup, run func()) {
+	group.Add(1)
+	go func() {
+		defer group.Done()
+		run()
+	}()
+}
+
+// shutdown drains the requests then stops the background jobs, both within SERVER_SHUTDOWN_TIMEOUT
+func shutdown(app *app.App, server *http.Server, stopWorkers context.CancelFunc, workersGroup *sync.WaitGroup) {
+	app.Drain()
+	ctx, cancel := context.WithTimeout(context.Background(), app.Config.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		app.Logger.Error("Requests were still running at the shutdown deadline", zap.Error(err))
+	}
+
+	stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		workersGroup.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		app.Logger.Info("Background jobs stopped")
+	case <-ctx.Done():
+		app.Logger.Error("Background jobs were still running at the shutdown deadline")
 	}
 }
 
-func printAppInfo() {
+func printAppInfo(log *zap.Logger, cfg *config.Config) {
 	info := fmt.Sprintf(`
 	InstructHub API
 	Version: %s
 	Gin Version: %s
 	Domain: %s
-	`, os.Getenv("VERSION"), gin.Version, os.Getenv("BASE_URL"))
-	logger.Log.Info(info)
+	Profile: %s
+	`, cfg.Server.Version, gin.Version, cfg.Server.BaseURL, cfg.Profile)
+	log.Info(info)
 }
 
-func route(r *gin.RouterGroup) {
-	routes.AuthRoute(r)
-	routes.UserRoute(r)
-	routes.CourseRoute(r)
+func route(r *gin.RouterGroup, app *app.App) {
+	routes.AuthRoute(r, app)
+	routes.UserRoute(r, app)
+	routes.CourseRoute(r, app)
+	routes.StatusRoute(r, app)
 }
@@ -3,58 +3,42 @@ package cache
 import (
 	"context"
 	"fmt"
-	"os"
 
-	"github.com/instructhub/backend/pkg/logger"
+	"github.com/instructhub/backend/pkg/tracing"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-var (
-	RedisClient *redis.Client
-	RedisLimiter *redis.Client
+const (
+	// NormalDB keeps the cached data, like verification tokens and invitations
+	NormalDB = 0
+	// LimiterDB keeps the rate limiter counters
+	LimiterDB = 1
 )
 
-// InitializeRedis initializes the Redis client.
-func init() {
-	// Read Redis database number.
-	limiterDB := 1
-	normalDB := 0
-
-	host := os.Getenv("CACHE_HOST")
-	port := os.Getenv("CACHE_PORT")
-	if host == "" || port == "" {
-		logger.Log.Fatal("CACHE_HOST or CACHE_PORT is not set")
-	}
-
-	url := fmt.Sprintf("%s:%s", host, port)
-
-	// Set Redis options.
-	options := &redis.Options{
-		Addr:         url,
-		Password:     os.Getenv("CACHE_PASSWORD"),
-		DB:           normalDB,
-		PoolSize:     10,
-		MinIdleConns: 1,
-	}
-
-	RedisClient = redis.NewClient(options)
-
-	options = &redis.Options{
-		Addr:         url,
-		Password:     os.Getenv("CACHE_PASSWORD"),
-		DB:           limiterDB,
+// Connect connects to a database of the Redis server and checks it answers
+func Connect(addr, password string, db int) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
 		PoolSize:     10,
 		MinIdleConns: 1,
-	}
-	
-	RedisLimiter = redis.NewClient(options)
+	})
 
 	// Test connection
-	if err := RedisClient.Ping(context.Background()).Err(); err != nil {
-		logger.Log.Fatal(fmt.Sprintf("Failed to connect to Redis: %v", err))
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	if err := RedisLimiter.Ping(context.Background()).Err(); err != nil {
-		logger.Log.Fatal(fmt.Sprintf("Failed to connect to Redis: %v", err))
+	// Trace the commands without their arguments, the keys hold tokens
+	if err := redisotel.InstrumentTracing(client,
+		redisotel.WithDBStatement(false),
+		redisotel.WithTracerProvider(tracing.ChildTracerProvider()),
+	); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to trace Redis: %w", err)
 	}
+	return client, nil
 }
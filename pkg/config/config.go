@@ -0,0 +1,275 @@
+// Package config loads the settings of the application.
+//
+// Every setting has an environment variable name, the sources are read from the
+// lowest to the highest priority:
+//
+//  1. the defaults of the fields
+//  2. config.yaml then config.<profile>.yaml, nested keys are joined with "_" so
+//     database: {host: x} sets DATABASE_HOST
+//  3. .env then .env.<profile>, in the format of template.env
+//  4. the environment variables
+//
+// The profile is picked by APP_ENV.
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config holds every setting of the application
+type Config struct {
+	// Profile is the name of the environment, like development or production
+	Profile string `env:"APP_ENV"`
+
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Cache     CacheConfig
+	Content   ContentConfig
+	Gitea     GiteaConfig
+	Storage   StorageConfig
+	S3        S3Config
+	Argon2    Argon2Config
+	Auth      AuthConfig
+	OAuth     OAuthConfig
+	SMTP      SMTPConfig
+	Tracing   TracingConfig
+	RateLimit RateLimitConfig
+}
+
+type ServerConfig struct {
+	GinMode string `env:"GIN_MODE" default:"release" oneof:"debug release test"`
+	Port    string `env:"PORT" default:"8080"`
+	// Version is the API version, the routes are served under /api/v{Version}
+	Version string `env:"VERSION" required:"true"`
+	// MachineID must be unique among the running instances, it is part of the generated IDs
+	MachineID uint16 `env:"MACHINE_ID" required:"true"`
+	BaseURL   string `env:"BASE_URL" required:"true"`
+	// MetricsToken protects /metrics, Prometheus sends it as a bearer token, it is required in production
+	// and /metrics is public when empty
+	MetricsToken string `env:"METRICS_TOKEN" secret:"true"`
+	// HealthCheckTimeout bounds each dependency probe of /readyz and /status
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	// ReadHeaderTimeout and ReadTimeout bound reading a request, ReadTimeout includes the uploaded body
+	ReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" default:"10s"`
+	ReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" default:"60s"`
+	// WriteTimeout bounds handling a request, long enough for a merge on Gitea
+	WriteTimeout time.Duration `env:"SERVER_WRITE_TIMEOUT" default:"120s"`
+	IdleTimeout  time.Duration `env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	// ShutdownTimeout is how long the requests and the workers are waited for on SIGTERM
+	ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s"`
+}
+
+type DatabaseConfig struct {
+	Host     string `env:"DATABASE_HOST" required:"true"`
+	User     string `env:"DATABASE_USER" required:"true"`
+	Password string `env:"DATABASE_PASSWORD" secret:"true"`
+	DBName   string `env:"DATABASE_DBNAME" required:"true"`
+	Port     string `env:"DATABASE_PORT" default:"5432"`
+	SSLMode  string `env:"DATABASE_SSLMODE" default:"prefer"`
+	// AutoMigrate applies the pending migrations when the app starts
+	AutoMigrate bool `env:"DATABASE_AUTO_MIGRATE" default:"true"`
+}
+
+type CacheConfig struct {
+	Host     string `env:"CACHE_HOST" required:"true"`
+	Port     string `env:"CACHE_PORT" default:"6379"`
+	Password string `env:"CACHE_PASSWORD" secret:"true"`
+}
+
+type ContentConfig struct {
+	Store string `env:"CONTENT_STORE" default:"gitea" oneof:"gitea local"`
+	Path  string `env:"CONTENT_STORE_PATH" default:"./data/content"`
+}
+
+type GiteaConfig struct {
+	URL         string `env:"GITEA_URL"`
+	Token       string `env:"GITEA_TOKEN" secret:"true"`
+	OrgName     string `env:"GITEA_ORG_NAME"`
+	CommitEmail string `env:"GITEA_COMMIT_EMAIL" required:"true"`
+	// RevisionBranchGracePeriod is how long the branch of a closed revision is kept
+	RevisionBranchGracePeriod time.Duration `env:"REVISION_BRANCH_GRACE_PERIOD" default:"168" unit:"h"`
+	// ConsistencyCheckInterval is how often the database is compared with the content store
+	ConsistencyCheckInterval time.Duration `env:"CONSISTENCY_CHECK_INTERVAL" default:"24" unit:"h"`
+}
+
+type StorageConfig struct {
+	Type string `env:"STORAGE" default:"s3" oneof:"s3 local"`
+	Path string `env:"STORAGE_PATH" default:"./data/uploads"`
+}
+
+type S3Config struct {
+	Endpoint      string `env:"S3_ENDPOINT"`
+	AccessKeyID   string `env:"S3_ACCESS_KEY_ID"`
+	SecretKey     string `env:"S3_SECRET_KEY" secret:"true"`
+	PathStyle     bool   `env:"S3_PATH_STYLE" default:"false"`
+	StaticBucket  string `env:"S3_STATIC_BUCKET"`
+	StaticBaseURL string `env:"S3_STATIC_BUCKET_BASEURL"`
+	TLSVerify     bool   `env:"S3_TLS_VERIFY" default:"true"`
+	CreateBucket  bool   `env:"S3_CREATE_BUCKET" default:"false"`
+}
+
+type Argon2Config struct {
+	// Memory is in KiB
+	Memory      uint32 `env:"ARGON2_MEMORY" default:"65536"`
+	Iterations  uint32 `env:"ARGON2_ITERATIONS" default:"20"`
+	Parallelism uint8  `env:"ARGON2_PARALLELISM" default:"4"`
+}
+
+type AuthConfig struct {
+	JWTSecretKey        string        `env:"JWT_SECRET_KEY" required:"true" secret:"true"`
+	RefreshTokenExpires time.Duration `env:"COOKIE_REFRESH_TOKEN_EXPIRES" default:"60" unit:"d"`
+	AccessTokenExpires  time.Duration `env:"COOKIE_ACCESS_TOKEN_EXPIRES" default:"15" unit:"m"`
+	// ReauthenticationWindow is how recent the login must be to set a password on an OAuth account
+	ReauthenticationWindow time.Duration `env:"REAUTHENTICATION_WINDOW" default:"10" unit:"m"`
+	// MFAEncryptionKey encrypts the TOTP secrets in the database, the base64 of 32 bytes, the two-factor
+	// authentication can't be enabled without it
+	MFAEncryptionKey string `env:"MFA_ENCRYPTION_KEY" secret:"true"`
+	// LoginFreeAttempts is how many failed logins of an account are allowed before waiting between the attempts
+	LoginFreeAttempts int `env:"LOGIN_FREE_ATTEMPTS" default:"3"`
+	// LoginMaxAttempts is how many failed logins lock the account for LoginLockDuration
+	LoginMaxAttempts  int           `env:"LOGIN_MAX_ATTEMPTS" default:"10"`
+	LoginLockDuration time.Duration `env:"LOGIN_LOCK_DURATION" default:"15" unit:"m"`
+	// LoginFailureWindow is how long the failed logins are remembered after the last one
+	LoginFailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" default:"15" unit:"m"`
+	// LoginMaxIPAttempts is how many failed logins from an IP stop its logins until the window ends
+	LoginMaxIPAttempts int `env:"LOGIN_MAX_IP_ATTEMPTS" default:"50"`
+}
+
+type OAuthConfig struct {
+	// SessionSecret signs the cookie keeping the state of an OAuth login
+	SessionSecret string              `env:"SESSION_SECRET" required:"true" secret:"true"`
+	Google        OAuthProviderConfig `prefix:"GOOGLE_"`
+	Github        OAuthProviderConfig `prefix:"GITHUB_"`
+	Gitlab        OAuthProviderConfig `prefix:"GITLAB_"`
+}
+
+type OAuthProviderConfig struct {
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET" secret:"true"`
+}
+
+type SMTPConfig struct {
+	Host     string `env:"SMTP_HOST" required:"true"`
+	Port     int    `env:"SMTP_PORT" default:"587"`
+	Username string `env:"SMTP_USERNAME"`
+	Key      string `env:"SMTP_KEY" secret:"true"`
+	From     string `env:"SMTP_FROM" required:"true"`
+}
+
+type TracingConfig struct {
+	// Exporter sends the spans to an OTLP collector over HTTP, prints them on stdout, or turns the tracing off
+	Exporter string `env:"TRACING_EXPORTER" default:"none" oneof:"none otlp stdout"`
+	// OTLPEndpoint is the host and port of the collector, like localhost:4318, OTEL_EXPORTER_OTLP_ENDPOINT is used when empty
+	OTLPEndpoint string `env:"TRACING_OTLP_ENDPOINT"`
+	// OTLPInsecure sends the spans over plain HTTP, for a collector running next to the app
+	OTLPInsecure bool `env:"TRACING_OTLP_INSECURE" default:"false"`
+	// SampleRatio is the share of the traces started here that are kept, the decision of the caller is followed otherwise
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" default:"1"`
+	ServiceName string  `env:"TRACING_SERVICE_NAME" default:"instructhub-backend"`
+}
+
+type RateLimitConfig struct {
+	// Store keeps the counters in Redis, shared by the instances, or in memory for a single instance, none turns the limits off
+	Store string `env:"RATE_LIMIT_STORE" default:"redis" oneof:"redis memory none"`
+}
+
+// BackendURL is the URL the API is served from
+func (config *Config) BackendURL() string {
+	return fmt.Sprintf("%s/api/v%s", config.Server.BaseURL, config.Server.Version)
+}
+
+// ValidationError lists every problem found in the settings
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validate checks the settings depending on each other, the fields are already parsed
+func (config *Config) validate() []string {
+	problems := []string{}
+	requireAll := func(reason string, settings map[string]string) {
+		for _, name := range sortedKeys(settings) {
+			if settings[name] == "" {
+				problems = append(problems, fmt.Sprintf("%s is required %s", name, reason))
+			}
+		}
+	}
+
+	if config.Server.MachineID > 1023 {
+		problems = append(problems, fmt.Sprintf("MACHINE_ID must be between 0 and 1023, got %d", config.Server.MachineID))
+	}
+	if !strings.HasPrefix(config.Server.BaseURL, "http://") && !strings.HasPrefix(config.Server.BaseURL, "https://") && config.Server.BaseURL != "" {
+		problems = append(problems, fmt.Sprintf("BASE_URL must start with http:// or https://, got %q", config.Server.BaseURL))
+	}
+
+	if config.Profile == "production" {
+		requireAll("in production", map[string]string{"METRICS_TOKEN": config.Server.MetricsToken})
+	}
+
+	switch config.Content.Store {
+	case "gitea":
+		requireAll("by the gitea content store", map[string]string{
+			"GITEA_URL":      config.Gitea.URL,
+			"GITEA_TOKEN":    config.Gitea.Token,
+			"GITEA_ORG_NAME": config.Gitea.OrgName,
+		})
+	case "local":
+		requireAll("by the local content store", map[string]string{"CONTENT_STORE_PATH": config.Content.Path})
+	}
+
+	switch config.Storage.Type {
+	case "s3":
+		requireAll("by the s3 storage", map[string]string{
+			"S3_ENDPOINT":              config.S3.Endpoint,
+			"S3_ACCESS_KEY_ID":         config.S3.AccessKeyID,
+			"S3_SECRET_KEY":            config.S3.SecretKey,
+			"S3_STATIC_BUCKET":         config.S3.StaticBucket,
+			"S3_STATIC_BUCKET_BASEURL": config.S3.StaticBaseURL,
+		})
+	case "local":
+		requireAll("by the local storage", map[string]string{"STORAGE_PATH": config.Storage.Path})
+	}
+
+	positive := map[string]time.Duration{
+		"HEALTH_CHECK_TIMEOUT":         config.Server.HealthCheckTimeout,
+		"SERVER_READ_HEADER_TIMEOUT":   config.Server.ReadHeaderTimeout,
+		"SERVER_READ_TIMEOUT":          config.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":         config.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":          config.Server.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT":      config.Server.ShutdownTimeout,
+		"REVISION_BRANCH_GRACE_PERIOD": config.Gitea.RevisionBranchGracePeriod,
+		"CONSISTENCY_CHECK_INTERVAL":   config.Gitea.ConsistencyCheckInterval,
+		"COOKIE_REFRESH_TOKEN_EXPIRES": config.Auth.RefreshTokenExpires,
+		"COOKIE_ACCESS_TOKEN_EXPIRES":  config.Auth.AccessTokenExpires,
+		"REAUTHENTICATION_WINDOW":      config.Auth.ReauthenticationWindow,
+		"LOGIN_LOCK_DURATION":          config.Auth.LoginLockDuration,
+		"LOGIN_FAILURE_WINDOW":         config.Auth.LoginFailureWindow,
+	}
+	for _, name := range sortedKeys(positive) {
+		if positive[name] <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive", name))
+		}
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", config.Tracing.SampleRatio))
+	}
+	if key := config.Auth.MFAEncryptionKey; key != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 32 {
+			problems = append(problems, "MFA_ENCRYPTION_KEY must be the base64 of 32 bytes, like the output of openssl rand -base64 32")
+		}
+	}
+	if config.Auth.LoginFreeAttempts < 0 || config.Auth.LoginMaxAttempts <= config.Auth.LoginFreeAttempts || config.Auth.LoginMaxIPAttempts <= 0 {
+		problems = append(problems, "LOGIN_MAX_ATTEMPTS must be greater than LOGIN_FREE_ATTEMPTS, LOGIN_MAX_IP_ATTEMPTS must be positive")
+	}
+	if config.Argon2.Memory == 0 || config.Argon2.Iterations == 0 || config.Argon2.Parallelism == 0 {
+		problems = append(problems, "ARGON2_MEMORY, ARGON2_ITERATIONS and ARGON2_PARALLELISM must be positive")
+	}
+
+	return problems
+}
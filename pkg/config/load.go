@@ -0,0 +1,259 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// units are the units of the durations set as a plain number
+var units = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// setting is a field of the configuration with its environment variable name
+type setting struct {
+	name  string
+	value reflect.Value
+	field reflect.StructField
+}
+
+// Load reads the settings of the profile named by APP_ENV
+func Load() (*Config, error) {
+	profile := os.Getenv("APP_ENV")
+	if profile == "" {
+		// The profile can also be picked in .env
+		if values, err := godotenv.Read(".env"); err == nil {
+			profile = values["APP_ENV"]
+		}
+	}
+	return LoadProfile(profile)
+}
+
+// LoadProfile reads the settings of a profile, the returned error lists every invalid setting.
+// The configuration is returned along with a *ValidationError so it can still be inspected
+func LoadProfile(profile string) (*Config, error) {
+	values := map[string]string{}
+	problems := []string{}
+
+	yamlFiles := []string{"config.yaml"}
+	envFiles := []string{".env"}
+	if profile != "" {
+		yamlFiles = append(yamlFiles, "config."+profile+".yaml")
+		envFiles = append(envFiles, ".env."+profile)
+	}
+
+	foundProfile := profile == ""
+	for _, file := range yamlFiles {
+		found, err := readYAMLFile(file, values)
+		if err != nil {
+			return nil, err
+		}
+		foundProfile = foundProfile || (found && file != "config.yaml")
+	}
+	for _, file := range envFiles {
+		found, err := readEnvFile(file, values)
+		if err != nil {
+			return nil, err
+		}
+		foundProfile = foundProfile || (found && file != ".env")
+	}
+	if !foundProfile {
+		problems = append(problems, fmt.Sprintf("profile %q has neither a .env.%s nor a config.%s.yaml file", profile, profile, profile))
+	}
+
+	config := &Config{}
+	for _, s := range settings(config) {
+		if value, ok := os.LookupEnv(s.name); ok {
+			values[s.name] = value
+		}
+	}
+	values["APP_ENV"] = profile
+
+	for _, s := range settings(config) {
+		if err := s.set(values); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	problems = append(problems, config.validate()...)
+
+	if len(problems) > 0 {
+		return config, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
+
+// readEnvFile reads a file in the format of template.env, a missing file is skipped
+func readEnvFile(path string, values map[string]string) (bool, error) {
+	fileValues, err := godotenv.Read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for name, value := range fileValues {
+		values[name] = value
+	}
+	return true, nil
+}
+
+// readYAMLFile reads a YAML file, a missing file is skipped
+func readYAMLFile(path string, values map[string]string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := flattenYAML("", document, values); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// flattenYAML joins the nested keys with "_" to get the name of the environment variables
+func flattenYAML(prefix string, document map[string]interface{}, values map[string]string) error {
+	for key, value := range document {
+		name := strings.ToUpper(prefix + key)
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flattenYAML(name+"_", value, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s: lists are not supported", name)
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// settings lists the fields of the configuration in order
+func settings(config *Config) []setting {
+	return collectSettings(reflect.ValueOf(config).Elem(), "")
+}
+
+func collectSettings(value reflect.Value, prefix string) []setting {
+	result := []setting{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			result = append(result, collectSettings(value.Field(i), prefix+field.Tag.Get("prefix"))...)
+			continue
+		}
+		result = append(result, setting{name: prefix + field.Tag.Get("env"), value: value.Field(i), field: field})
+	}
+	return result
+}
+
+// set parses the value of the setting, falling back to its default
+func (s setting) set(values map[string]string) error {
+	raw, ok := values[s.name]
+	if !ok || raw == "" {
+		if s.field.Tag.Get("required") == "true" {
+			return fmt.Errorf("%s is required", s.name)
+		}
+		raw, ok = s.field.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+	}
+
+	if oneOf := s.field.Tag.Get("oneof"); oneOf != "" && !contains(strings.Fields(oneOf), raw) {
+		return fmt.Errorf("%s must be one of %s, got %q", s.name, strings.Join(strings.Fields(oneOf), ", "), raw)
+	}
+
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(raw)
+	case bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", s.name, raw)
+		}
+		s.value.SetBool(parsed)
+	case time.Duration:
+		parsed, err := parseDuration(raw, s.field.Tag.Get("unit"))
+		if err != nil {
+			return fmt.Errorf("%s must be a number of %s or a duration like 90m, got %q", s.name, unitName(s.field.Tag.Get("unit")), raw)
+		}
+		s.value.SetInt(int64(parsed))
+	case int:
+		parsed, err := strconv.ParseInt(raw, 10, 0)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", s.name, raw)
+		}
+		s.value.SetInt(parsed)
+	case float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", s.name, raw)
+		}
+		s.value.SetFloat(parsed)
+	case uint8, uint16, uint32:
+		parsed, err := strconv.ParseUint(raw, 10, s.value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s must be an integer between 0 and %d, got %q", s.name, uint64(1)<<s.value.Type().Bits()-1, raw)
+		}
+		s.value.SetUint(parsed)
+	default:
+		return fmt.Errorf("%s has an unsupported type %s", s.name, s.value.Type())
+	}
+	return nil
+}
+
+// parseDuration parses a plain number in the unit, or a Go duration
+func parseDuration(raw, unit string) (time.Duration, error) {
+	if number, err := strconv.ParseInt(raw, 10, 64); err == nil && units[unit] != 0 {
+		return time.Duration(number) * units[unit], nil
+	}
+	return time.ParseDuration(raw)
+}
+
+func unitName(unit string) string {
+	switch unit {
+	case "m":
+		return "minutes"
+	case "h":
+		return "hours"
+	case "d":
+		return "days"
+	}
+	return "nanoseconds"
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
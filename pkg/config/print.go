@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces the secrets when printing redacted
+const redactedValue = "********"
+
+// Print writes the settings in the format of template.env, redacted hides the secrets
+func (config *Config) Print(w io.Writer, redacted bool) error {
+	for _, s := range settings(config) {
+		value := formatValue(s)
+		if redacted && value != "" && s.field.Tag.Get("secret") == "true" {
+			value = redactedValue
+		}
+		if strings.ContainsAny(value, " #\"'") {
+			value = strconv.Quote(value)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", s.name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatValue formats the setting the way it is written in an env file
+func formatValue(s setting) string {
+	switch value := s.value.Interface().(type) {
+	case time.Duration:
+		if unit := units[s.field.Tag.Get("unit")]; unit != 0 && value%unit == 0 {
+			return strconv.FormatInt(int64(value/unit), 10)
+		}
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,136 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// ErrNotFound is returned when a repository, ref, file or change request doesn't exist
+var ErrNotFound = errors.New("content: not found")
+
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationDelete Operation = "delete"
+	OperationUpdate Operation = "update"
+)
+
+// File is a single file change of a commit
+type File struct {
+	Path      string
+	Content   []byte
+	Operation Operation
+}
+
+// Identity is the author of a commit
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// CommitRequest describes a commit of several files
+type CommitRequest struct {
+	// Branch the commit is made on, or started from when NewBranch is set
+	Branch    string
+	NewBranch string
+	Message   string
+	Author    Identity
+	Files     []File
+}
+
+// Commit is a commit of a repository
+type Commit struct {
+	SHA     string
+	Message string
+	Parents []string
+}
+
+// Parent returns the first parent of the commit, empty for a root commit
+func (commit Commit) Parent() string {
+	if len(commit.Parents) == 0 {
+		return ""
+	}
+	return commit.Parents[0]
+}
+
+// FileInfo is a file of a repository and the SHA of its content
+type FileInfo struct {
+	Path string
+	SHA  string
+}
+
+type ChangeRequestState string
+
+const (
+	ChangeRequestOpen   ChangeRequestState = "open"
+	ChangeRequestClosed ChangeRequestState = "closed"
+)
+
+// ChangeRequest asks to merge a branch into another, it is a pull request on Gitea
+type ChangeRequest struct {
+	Index int64
+	Title string
+	Head  string
+	Base  string
+	// MergeBase is the last commit of base the head branch contains
+	MergeBase string
+	State     ChangeRequestState
+	Merged    bool
+}
+
+type ReviewState string
+
+const (
+	ReviewComment        ReviewState = "comment"
+	ReviewApprove        ReviewState = "approve"
+	ReviewRequestChanges ReviewState = "request_changes"
+)
+
+// ContentStore keeps the git repositories holding the content of the courses, ctx carries
+// the deadline and the trace of the caller
+type ContentStore interface {
+	// CreateRepo creates a repository with an initial commit on its default branch
+	CreateRepo(ctx context.Context, repo, defaultBranch string) error
+	RepoExists(ctx context.Context, repo string) (bool, error)
+	ListRepos(ctx context.Context) ([]string, error)
+
+	// ReadFile returns the content of a file at a branch or commit
+	ReadFile(ctx context.Context, repo, ref, path string) ([]byte, error)
+	// ListFiles returns the files at the root of the repository at a branch or commit
+	ListFiles(ctx context.Context, repo, ref string) ([]FileInfo, error)
+
+	// GetBranch returns the last commit of a branch
+	GetBranch(ctx context.Context, repo, branch string) (Commit, error)
+	DeleteBranch(ctx context.Context, repo, branch string) error
+	// CommitFiles applies all the file changes in a single commit
+	CommitFiles(ctx context.Context, repo string, request CommitRequest) (Commit, error)
+
+	OpenChangeRequest(ctx context.Context, repo, head, base, title string) (ChangeRequest, error)
+	// FindChangeRequest returns the open change request of the head branch
+	FindChangeRequest(ctx context.Context, repo, head string) (ChangeRequest, error)
+	GetChangeRequest(ctx context.Context, repo string, index int64) (ChangeRequest, error)
+	SetChangeRequestState(ctx context.Context, repo string, index int64, state ChangeRequestState) error
+	// ChangedFiles returns the paths changed by the change request
+	ChangedFiles(ctx context.Context, repo string, index int64) ([]string, error)
+	MergeChangeRequest(ctx context.Context, repo string, index int64) error
+
+	// Comment and Review return the ID of the created comment or review
+	Comment(ctx context.Context, repo string, index int64, body string) (int64, error)
+	Review(ctx context.Context, repo string, index int64, state ReviewState, body string) (int64, error)
+	// FindComment and FindReview return the ID of the first comment or review whose body ends with
+	// the marker, ErrNotFound when there is none
+	FindComment(ctx context.Context, repo string, index int64, marker string) (int64, error)
+	FindReview(ctx context.Context, repo string, index int64, marker string) (int64, error)
+
+	// Ping checks the store can be reached
+	Ping(ctx context.Context) error
+}
+
+// CommitEmail returns the email of the commits made for a user
+func CommitEmail(id uint64) string {
+	return fmt.Sprintf("%s@%s", utils.Uint64ToStr(id), utils.GiteaCommitEmail)
+}
@@ -0,0 +1,486 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/instructhub/backend/pkg/tracing"
+)
+
+// GiteaStore keeps the repositories in a Gitea organization
+type GiteaStore struct {
+	httpClient *http.Client
+	url        string
+	token      string
+	org        string
+	// version of the server, the SDK checks it before calling the newer endpoints
+	version string
+}
+
+// NewGiteaStore connects to the Gitea server, every repository is created in org
+func NewGiteaStore(url, token, org string) (*GiteaStore, error) {
+	// Every request is traced and carries the trace context to Gitea
+	httpClient := &http.Client{Transport: tracing.Transport(http.DefaultTransport)}
+	client, err := gitea.NewClient(url, gitea.SetToken(token), gitea.SetHTTPClient(httpClient))
+	if err != nil {
+		return nil, err
+	}
+	version, _, err := client.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	return &GiteaStore{httpClient: httpClient, url: url, token: token, org: org, version: version}, nil
+}
+
+// client returns a client of the SDK sending its requests with ctx, the SDK keeps a single context per client
+func (store *GiteaStore) client(ctx context.Context) *gitea.Client {
+	// The version was parsed by NewGiteaStore so no option can fail
+	client, _ := gitea.NewClient(store.url,
+		gitea.SetToken(store.token),
+		gitea.SetHTTPClient(store.httpClient),
+		gitea.SetGiteaVersion(store.version),
+		gitea.SetContext(ctx),
+	)
+	return client
+}
+
+// Close closes the idle connections to the server
+func (store *GiteaStore) Close() error {
+	store.httpClient.CloseIdleConnections()
+	return nil
+}
+
+func (store *GiteaStore) CreateRepo(ctx context.Context, repo, defaultBranch string) error {
+	_, _, err := store.client(ctx).CreateOrgRepo(store.org, gitea.CreateRepoOption{
+		Name:          repo,
+		DefaultBranch: defaultBranch,
+		AutoInit:      true,
+		Private:       true,
+	})
+	return err
+}
+
+func (store *GiteaStore) RepoExists(ctx context.Context, repo string) (bool, error) {
+	_, response, err := store.client(ctx).GetRepo(store.org, repo)
+	if isNotFound(response) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (store *GiteaStore) ListRepos(ctx context.Context) ([]string, error) {
+	names := []string{}
+
+	options := gitea.ListOrgReposOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	client := store.client(ctx)
+	for {
+		repos, response, err := client.ListOrgRepos(store.org, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			names = append(names, repo.Name)
+		}
+		if response == nil || response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+
+	return names, nil
+}
+
+func (store *GiteaStore) ReadFile(ctx context.Context, repo, ref, path string) ([]byte, error) {
+	file, response, err := store.client(ctx).GetContents(store.org, repo, ref, path)
+	if isNotFound(response) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if file.Content == nil {
+		return []byte{}, nil
+	}
+	return base64.StdEncoding.DecodeString(*file.Content)
+}
+
+func (store *GiteaStore) ListFiles(ctx context.Context, repo, ref string) ([]FileInfo, error) {
+	contents, response, err := store.client(ctx).ListContents(store.org, repo, ref, "")
+	if isNotFound(response) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	files := []FileInfo{}
+	for _, content := range contents {
+		if content.Type == "file" {
+			files = append(files, FileInfo{Path: content.Path, SHA: content.SHA})
+		}
+	}
+	return files, nil
+}
+
+func (store *GiteaStore) GetBranch(ctx context.Context, repo, branch string) (Commit, error) {
+	giteaBranch, response, err := store.client(ctx).GetRepoBranch(store.org, repo, branch)
+	if isNotFound(response) {
+		return Commit{}, ErrNotFound
+	} else if err != nil {
+		return Commit{}, err
+	}
+
+	commit, _, err := store.client(ctx).GetSingleCommit(store.org, repo, giteaBranch.Commit.ID)
+	if err != nil {
+		return Commit{}, err
+	}
+
+	parents := []string{}
+	for _, parent := range commit.Parents {
+		if parent != nil {
+			parents = append(parents, parent.SHA)
+		}
+	}
+	message := ""
+	if commit.RepoCommit != nil {
+		message = commit.RepoCommit.Message
+	}
+	return Commit{SHA: commit.SHA, Message: message, Parents: parents}, nil
+}
+
+func (store *GiteaStore) DeleteBranch(ctx context.Context, repo, branch string) error {
+	deleted, response, err := store.client(ctx).DeleteRepoBranch(store.org, repo, branch)
+	if isNotFound(response) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("branch %s was not deleted, status %d", branch, response.StatusCode)
+	}
+	return nil
+}
+
+// modifyFile is a single file operation of the Gitea API
+type modifyFile struct {
+	Content   string    `json:"content"`
+	Operation Operation `json:"operation"`
+	Path      string    `json:"path"`
+}
+
+// modifyRequest represents the JSON body structure for the API request.
+type modifyRequest struct {
+	Author    gitea.Identity `json:"author"`
+	Branch    string         `json:"branch"`
+	Committer gitea.Identity `json:"committer"`
+	Files     []modifyFile   `json:"files"`
+	Message   string         `json:"message"`
+	NewBranch string         `json:"new_branch"`
+	Signoff   bool           `json:"signoff"`
+}
+
+// modifyResponse represents the JSON body structure of the API response.
+type modifyResponse struct {
+	Commit *gitea.FileCommitResponse `json:"commit"`
+}
+
+// CommitFiles sends a request to the Gitea API to modify multiple files, the SDK doesn't support it yet
+func (store *GiteaStore) CommitFiles(ctx context.Context, repo string, request CommitRequest) (Commit, error) {
+	identity := gitea.Identity{Name: request.Author.Name, Email: request.Author.Email}
+	body := modifyRequest{
+		Author:    identity,
+		Branch:    request.Branch,
+		Committer: identity,
+		Message:   request.Message,
+		NewBranch: request.NewBranch,
+	}
+	for _, file := range request.Files {
+		body.Files = append(body.Files, modifyFile{
+			Content:   base64.StdEncoding.EncodeToString(file.Content),
+			Operation: file.Operation,
+			Path:      file.Path,
+		})
+	}
+
+	// Marshal the request body into JSON
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	// Create the HTTP request
+	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/contents", store.url, store.org, repo)
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Set headers
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Authorization", fmt.Sprintf("token %s", store.token))
+
+	// Send the HTTP request
+	response, err := store.httpClient.Do(httpRequest)
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer response.Body.Close()
+
+	// Check the response status
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(response.Body)
+		return Commit{}, fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body))
+	}
+
+	// Decode the created commit
+	var modified modifyResponse
+	if err := json.NewDecoder(response.Body).Decode(&modified); err != nil {
+		return Commit{}, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	if modified.Commit == nil {
+		return Commit{}, fmt.Errorf("API response is missing the commit")
+	}
+
+	commit := Commit{SHA: modified.Commit.SHA, Message: modified.Commit.Message}
+	for _, parent := range modified.Commit.Parents {
+		if parent != nil {
+			commit.Parents = append(commit.Parents, parent.SHA)
+		}
+	}
+	return commit, nil
+}
+
+func (store *GiteaStore) OpenChangeRequest(ctx context.Context, repo, head, base, title string) (ChangeRequest, error) {
+	pullRequest, _, err := store.client(ctx).CreatePullRequest(store.org, repo, gitea.CreatePullRequestOption{
+		Head:  head,
+		Base:  base,
+		Title: title,
+	})
+	if err != nil {
+		return ChangeRequest{}, err
+	}
+	return toChangeRequest(pullRequest), nil
+}
+
+func (store *GiteaStore) FindChangeRequest(ctx context.Context, repo, head string) (ChangeRequest, error) {
+	options := gitea.ListPullRequestsOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}, State: gitea.StateOpen}
+	client := store.client(ctx)
+	for {
+		pullRequests, response, err := client.ListRepoPullRequests(store.org, repo, options)
+		if err != nil {
+			return ChangeRequest{}, err
+		}
+		for _, pullRequest := range pullRequests {
+			if pullRequest.Head != nil && pullRequest.Head.Ref == head {
+				return toChangeRequest(pullRequest), nil
+			}
+		}
+		if response == nil || response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+
+	return ChangeRequest{}, ErrNotFound
+}
+
+func (store *GiteaStore) GetChangeRequest(ctx context.Context, repo string, index int64) (ChangeRequest, error) {
+	pullRequest, response, err := store.client(ctx).GetPullRequest(store.org, repo, index)
+	if isNotFound(response) {
+		return ChangeRequest{}, ErrNotFound
+	} else if err != nil {
+		return ChangeRequest{}, err
+	}
+	return toChangeRequest(pullRequest), nil
+}
+
+func (store *GiteaStore) SetChangeRequestState(ctx context.Context, repo string, index int64, state ChangeRequestState) error {
+	giteaState := gitea.StateOpen
+	if state == ChangeRequestClosed {
+		giteaState = gitea.StateClosed
+	}
+	_, _, err := store.client(ctx).EditPullRequest(store.org, repo, index, gitea.EditPullRequestOption{
+		State: &giteaState,
+	})
+	return err
+}
+
+func (store *GiteaStore) ChangedFiles(ctx context.Context, repo string, index int64) ([]string, error) {
+	paths := []string{}
+
+	options := gitea.ListPullRequestFilesOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	client := store.client(ctx)
+	for {
+		files, response, err := client.ListPullRequestFiles(store.org, repo, index, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			paths = append(paths, file.Filename)
+		}
+		if response == nil || response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+
+	return paths, nil
+}
+
+func (store *GiteaStore) MergeChangeRequest(ctx context.Context, repo string, index int64) error {
+	merged, response, err := store.client(ctx).MergePullRequest(store.org, repo, index, gitea.MergePullRequestOption{
+		Style: gitea.MergeStyleMerge,
+	})
+	if err != nil {
+		return err
+	}
+	if !merged {
+		return fmt.Errorf("pull request was not merged, status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (store *GiteaStore) Comment(ctx context.Context, repo string, index int64, body string) (int64, error) {
+	comment, _, err := store.client(ctx).CreateIssueComment(store.org, repo, index, gitea.CreateIssueCommentOption{
+		Body: body,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return comment.ID, nil
+}
+
+var reviewStates = map[ReviewState]gitea.ReviewStateType{
+	ReviewComment:        gitea.ReviewStateComment,
+	ReviewApprove:        gitea.ReviewStateApproved,
+	ReviewRequestChanges: gitea.ReviewStateRequestChanges,
+}
+
+func (store *GiteaStore) Review(ctx context.Context, repo string, index int64, state ReviewState, body string) (int64, error) {
+	review, response, err := store.client(ctx).CreatePullReview(store.org, repo, index, gitea.CreatePullReviewOptions{
+		State: reviewStates[state],
+		Body:  body,
+	})
+	if err != nil && state != ReviewComment && isClientError(response) && store.isOwnPullRequest(ctx, repo, index) {
+		// Gitea doesn't let the account that opened the pull request approve it, keep the verdict in the body instead
+		review, _, err = store.client(ctx).CreatePullReview(store.org, repo, index, gitea.CreatePullReviewOptions{
+			State: gitea.ReviewStateComment,
+			Body:  body,
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+	return review.ID, nil
+}
+
+func (store *GiteaStore) FindComment(ctx context.Context, repo string, index int64, marker string) (int64, error) {
+	options := gitea.ListIssueCommentOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	client := store.client(ctx)
+	for {
+		comments, response, err := client.ListIssueComments(store.org, repo, index, options)
+		if err != nil {
+			return 0, err
+		}
+		for _, comment := range comments {
+			if strings.HasSuffix(strings.TrimSpace(comment.Body), marker) {
+				return comment.ID, nil
+			}
+		}
+		if response == nil || response.NextPage == 0 {
+			return 0, ErrNotFound
+		}
+		options.Page = response.NextPage
+	}
+}
+
+func (store *GiteaStore) FindReview(ctx context.Context, repo string, index int64, marker string) (int64, error) {
+	options := gitea.ListPullReviewsOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	client := store.client(ctx)
+	for {
+		reviews, response, err := client.ListPullReviews(store.org, repo, index, options)
+		if err != nil {
+			return 0, err
+		}
+		for _, review := range reviews {
+			if strings.HasSuffix(strings.TrimSpace(review.Body), marker) {
+				return review.ID, nil
+			}
+		}
+		if response == nil || response.NextPage == 0 {
+			return 0, ErrNotFound
+		}
+		options.Page = response.NextPage
+	}
+}
+
+// isOwnPullRequest reports whether the pull request was opened by the account of the store,
+// it is false when Gitea can't tell so the original error is kept
+func (store *GiteaStore) isOwnPullRequest(ctx context.Context, repo string, index int64) bool {
+	pullRequest, _, err := store.client(ctx).GetPullRequest(store.org, repo, index)
+	if err != nil || pullRequest.Poster == nil {
+		return false
+	}
+	user, _, err := store.client(ctx).GetMyUserInfo()
+	if err != nil {
+		return false
+	}
+	return pullRequest.Poster.ID == user.ID
+}
+
+// toChangeRequest converts a Gitea pull request
+func toChangeRequest(pullRequest *gitea.PullRequest) ChangeRequest {
+	changeRequest := ChangeRequest{
+		Index:     pullRequest.Index,
+		Title:     pullRequest.Title,
+		MergeBase: pullRequest.MergeBase,
+		State:     ChangeRequestOpen,
+		Merged:    pullRequest.HasMerged,
+	}
+	if pullRequest.State == gitea.StateClosed {
+		changeRequest.State = ChangeRequestClosed
+	}
+	if pullRequest.Head != nil {
+		changeRequest.Head = pullRequest.Head.Ref
+	}
+	if pullRequest.Base != nil {
+		changeRequest.Base = pullRequest.Base.Ref
+	}
+	return changeRequest
+}
+
+// Ping checks Gitea answers and the token can read the organization
+func (store *GiteaStore) Ping(ctx context.Context) error {
+	url := fmt.Sprintf("%s/api/v1/orgs/%s", store.url, store.org)
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpRequest.Header.Set("Authorization", fmt.Sprintf("token %s", store.token))
+
+	response, err := store.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("gitea answered with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// isNotFound reports whether Gitea answered 404
+func isNotFound(response *gitea.Response) bool {
+	return response != nil && response.StatusCode == http.StatusNotFound
+}
+
+// isClientError reports whether Gitea refused the request with a 4xx, timeouts and 5xx aren't refusals
+func isClientError(response *gitea.Response) bool {
+	return response != nil && response.StatusCode >= 400 && response.StatusCode < 500
+}
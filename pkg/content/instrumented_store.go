@@ -0,0 +1,190 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/instructhub/backend/pkg/metrics"
+	"github.com/instructhub/backend/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("github.com/instructhub/backend/pkg/content")
+
+// instrumentedStore measures and traces every call to the store it wraps
+type instrumentedStore struct {
+	next    ContentStore
+	backend string
+}
+
+// Instrument records the duration of every call to store and starts a span for it, backend names it
+// in the metrics and the spans
+func Instrument(store ContentStore, backend string) ContentStore {
+	return &instrumentedStore{next: store, backend: backend}
+}
+
+// start starts the span of a call on a repository, repo is empty for the calls on the whole store
+func (store *instrumentedStore) start(ctx context.Context, operation, repo string) (context.Context, trace.Span, time.Time) {
+	ctx, span := tracing.StartChild(ctx, tracer, "content."+operation, trace.WithAttributes(
+		attribute.String("content.backend", store.backend),
+		attribute.String("content.repo", repo),
+	))
+	return ctx, span, time.Now()
+}
+
+// observe records a call and ends its span, a missing file or branch is an expected answer rather than an error
+func (store *instrumentedStore) observe(span trace.Span, operation string, start time.Time, err error) {
+	result := metrics.Result(err)
+	if errors.Is(err, ErrNotFound) {
+		result = "not_found"
+		span.SetAttributes(attribute.Bool("content.not_found", true))
+		err = nil
+	}
+	metrics.ObserveCall(metrics.ContentStoreDuration, store.backend, operation, start, result)
+	tracing.End(span, err)
+}
+
+// Close closes the wrapped store when it holds resources
+func (store *instrumentedStore) Close() error {
+	if closer, ok := store.next.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (store *instrumentedStore) CreateRepo(ctx context.Context, repo, defaultBranch string) error {
+	ctx, span, start := store.start(ctx, "create_repo", repo)
+	err := store.next.CreateRepo(ctx, repo, defaultBranch)
+	store.observe(span, "create_repo", start, err)
+	return err
+}
+
+func (store *instrumentedStore) RepoExists(ctx context.Context, repo string) (bool, error) {
+	ctx, span, start := store.start(ctx, "repo_exists", repo)
+	exists, err := store.next.RepoExists(ctx, repo)
+	store.observe(span, "repo_exists", start, err)
+	return exists, err
+}
+
+func (store *instrumentedStore) ListRepos(ctx context.Context) ([]string, error) {
+	ctx, span, start := store.start(ctx, "list_repos", "")
+	repos, err := store.next.ListRepos(ctx)
+	store.observe(span, "list_repos", start, err)
+	return repos, err
+}
+
+func (store *instrumentedStore) ReadFile(ctx context.Context, repo, ref, path string) ([]byte, error) {
+	ctx, span, start := store.start(ctx, "read_file", repo)
+	data, err := store.next.ReadFile(ctx, repo, ref, path)
+	store.observe(span, "read_file", start, err)
+	return data, err
+}
+
+func (store *instrumentedStore) ListFiles(ctx context.Context, repo, ref string) ([]FileInfo, error) {
+	ctx, span, start := store.start(ctx, "list_files", repo)
+	files, err := store.next.ListFiles(ctx, repo, ref)
+	store.observe(span, "list_files", start, err)
+	return files, err
+}
+
+func (store *instrumentedStore) GetBranch(ctx context.Context, repo, branch string) (Commit, error) {
+	ctx, span, start := store.start(ctx, "get_branch", repo)
+	commit, err := store.next.GetBranch(ctx, repo, branch)
+	store.observe(span, "get_branch", start, err)
+	return commit, err
+}
+
+func (store *instrumentedStore) DeleteBranch(ctx context.Context, repo, branch string) error {
+	ctx, span, start := store.start(ctx, "delete_branch", repo)
+	err := store.next.DeleteBranch(ctx, repo, branch)
+	store.observe(span, "delete_branch", start, err)
+	return err
+}
+
+func (store *instrumentedStore) CommitFiles(ctx context.Context, repo string, request CommitRequest) (Commit, error) {
+	ctx, span, start := store.start(ctx, "commit_files", repo)
+	span.SetAttributes(attribute.Int("content.files", len(request.Files)))
+	commit, err := store.next.CommitFiles(ctx, repo, request)
+	store.observe(span, "commit_files", start, err)
+	return commit, err
+}
+
+func (store *instrumentedStore) OpenChangeRequest(ctx context.Context, repo, head, base, title string) (ChangeRequest, error) {
+	ctx, span, start := store.start(ctx, "open_change_request", repo)
+	changeRequest, err := store.next.OpenChangeRequest(ctx, repo, head, base, title)
+	store.observe(span, "open_change_request", start, err)
+	return changeRequest, err
+}
+
+func (store *instrumentedStore) FindChangeRequest(ctx context.Context, repo, head string) (ChangeRequest, error) {
+	ctx, span, start := store.start(ctx, "find_change_request", repo)
+	changeRequest, err := store.next.FindChangeRequest(ctx, repo, head)
+	store.observe(span, "find_change_request", start, err)
+	return changeRequest, err
+}
+
+func (store *instrumentedStore) GetChangeRequest(ctx context.Context, repo string, index int64) (ChangeRequest, error) {
+	ctx, span, start := store.start(ctx, "get_change_request", repo)
+	changeRequest, err := store.next.GetChangeRequest(ctx, repo, index)
+	store.observe(span, "get_change_request", start, err)
+	return changeRequest, err
+}
+
+func (store *instrumentedStore) SetChangeRequestState(ctx context.Context, repo string, index int64, state ChangeRequestState) error {
+	ctx, span, start := store.start(ctx, "set_change_request_state", repo)
+	err := store.next.SetChangeRequestState(ctx, repo, index, state)
+	store.observe(span, "set_change_request_state", start, err)
+	return err
+}
+
+func (store *instrumentedStore) ChangedFiles(ctx context.Context, repo string, index int64) ([]string, error) {
+	ctx, span, start := store.start(ctx, "changed_files", repo)
+	paths, err := store.next.ChangedFiles(ctx, repo, index)
+	store.observe(span, "changed_files", start, err)
+	return paths, err
+}
+
+func (store *instrumentedStore) MergeChangeRequest(ctx context.Context, repo string, index int64) error {
+	ctx, span, start := store.start(ctx, "merge_change_request", repo)
+	err := store.next.MergeChangeRequest(ctx, repo, index)
+	store.observe(span, "merge_change_request", start, err)
+	return err
+}
+
+func (store *instrumentedStore) Comment(ctx context.Context, repo string, index int64, body string) (int64, error) {
+	ctx, span, start := store.start(ctx, "comment", repo)
+	id, err := store.next.Comment(ctx, repo, index, body)
+	store.observe(span, "comment", start, err)
+	return id, err
+}
+
+func (store *instrumentedStore) Review(ctx context.Context, repo string, index int64, state ReviewState, body string) (int64, error) {
+	ctx, span, start := store.start(ctx, "review", repo)
+	id, err := store.next.Review(ctx, repo, index, state, body)
+	store.observe(span, "review", start, err)
+	return id, err
+}
+
+func (store *instrumentedStore) FindComment(ctx context.Context, repo string, index int64, marker string) (int64, error) {
+	ctx, span, start := store.start(ctx, "find_comment", repo)
+	id, err := store.next.FindComment(ctx, repo, index, marker)
+	store.observe(span, "find_comment", start, err)
+	return id, err
+}
+
+func (store *instrumentedStore) FindReview(ctx context.Context, repo string, index int64, marker string) (int64, error) {
+	ctx, span, start := store.start(ctx, "find_review", repo)
+	id, err := store.next.FindReview(ctx, repo, index, marker)
+	store.observe(span, "find_review", start, err)
+	return id, err
+}
+
+func (store *instrumentedStore) Ping(ctx context.Context) error {
+	ctx, span, start := store.start(ctx, "ping", "")
+	err := store.next.Ping(ctx)
+	store.observe(span, "ping", start, err)
+	return err
+}
@@ -0,0 +1,411 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// changesFile is the file of a local repository holding its change requests
+const changesFile = "change_requests.json"
+
+// localChanges is the content of the changes file
+type localChanges struct {
+	LastIndex  int64         `json:"last_index"`
+	LastNoteID int64         `json:"last_note_id"`
+	Changes    []localChange `json:"changes"`
+}
+
+// localChange is a change request of a local repository
+type localChange struct {
+	Index     int64              `json:"index"`
+	Title     string             `json:"title"`
+	Head      string             `json:"head"`
+	Base      string             `json:"base"`
+	MergeBase string             `json:"merge_base"`
+	State     ChangeRequestState `json:"state"`
+	Merged    bool               `json:"merged"`
+	Notes     []localNote        `json:"notes"`
+}
+
+// localNote is a comment or a review on a change request
+type localNote struct {
+	ID        int64       `json:"id"`
+	Review    ReviewState `json:"review,omitempty"`
+	Body      string      `json:"body"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func (store *LocalStore) OpenChangeRequest(ctx context.Context, repo, head, base, title string) (ChangeRequest, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var created localChange
+	err := store.updateChanges(repo, func(repository *git.Repository, changes *localChanges) error {
+		mergeBase, err := branchMergeBase(repository, head, base)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes.Changes {
+			if change.Head == head && change.Base == base && change.State == ChangeRequestOpen {
+				return fmt.Errorf("branch %s already has an open change request", head)
+			}
+		}
+
+		changes.LastIndex++
+		created = localChange{Index: changes.LastIndex, Title: title, Head: head, Base: base, MergeBase: mergeBase, State: ChangeRequestOpen}
+		changes.Changes = append(changes.Changes, created)
+		return nil
+	})
+	return created.toChangeRequest(), err
+}
+
+func (store *LocalStore) FindChangeRequest(ctx context.Context, repo, head string) (ChangeRequest, error) {
+	repository, changes, err := store.readChanges(repo)
+	if err != nil {
+		return ChangeRequest{}, err
+	}
+	for _, change := range changes.Changes {
+		if change.Head == head && change.State == ChangeRequestOpen {
+			return currentChangeRequest(repository, change), nil
+		}
+	}
+	return ChangeRequest{}, ErrNotFound
+}
+
+func (store *LocalStore) GetChangeRequest(ctx context.Context, repo string, index int64) (ChangeRequest, error) {
+	repository, changes, err := store.readChanges(repo)
+	if err != nil {
+		return ChangeRequest{}, err
+	}
+	change := changes.find(index)
+	if change == nil {
+		return ChangeRequest{}, ErrNotFound
+	}
+	return currentChangeRequest(repository, *change), nil
+}
+
+func (store *LocalStore) SetChangeRequestState(ctx context.Context, repo string, index int64, state ChangeRequestState) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.updateChanges(repo, func(repository *git.Repository, changes *localChanges) error {
+		change := changes.find(index)
+		if change == nil {
+			return ErrNotFound
+		}
+		if change.Merged {
+			return fmt.Errorf("change request %d is already merged", index)
+		}
+		change.State = state
+		return nil
+	})
+}
+
+func (store *LocalStore) ChangedFiles(ctx context.Context, repo string, index int64) ([]string, error) {
+	repository, changes, err := store.readChanges(repo)
+	if err != nil {
+		return nil, err
+	}
+	change := changes.find(index)
+	if change == nil {
+		return nil, ErrNotFound
+	}
+
+	// Compare the head with where it left the base branch, like a pull request
+	current := currentChangeRequest(repository, *change)
+	baseTree, err := resolveTree(repository, current.MergeBase)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := resolveTree(repository, change.Head)
+	if err != nil {
+		return nil, err
+	}
+	treeChanges, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, treeChange := range treeChanges {
+		if treeChange.To.Name != "" {
+			paths = append(paths, treeChange.To.Name)
+		} else {
+			paths = append(paths, treeChange.From.Name)
+		}
+	}
+	return paths, nil
+}
+
+func (store *LocalStore) MergeChangeRequest(ctx context.Context, repo string, index int64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.updateChanges(repo, func(repository *git.Repository, changes *localChanges) error {
+		change := changes.find(index)
+		if change == nil {
+			return ErrNotFound
+		}
+		if change.Merged || change.State != ChangeRequestOpen {
+			return fmt.Errorf("change request %d is not open", index)
+		}
+
+		base, err := branchCommit(repository, change.Base)
+		if err != nil {
+			return err
+		}
+		head, err := branchCommit(repository, change.Head)
+		if err != nil {
+			return err
+		}
+		treeHash, err := mergeTrees(repository, base, head)
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("Merge branch '%s' into %s", change.Head, change.Base)
+		commitHash, err := writeCommit(repository, treeHash, []plumbing.Hash{base.Hash, head.Hash}, message, Identity{Name: "InstructHub"})
+		if err != nil {
+			return err
+		}
+		err = repository.Storer.CheckAndSetReference(
+			plumbing.NewHashReference(plumbing.NewBranchReferenceName(change.Base), commitHash),
+			plumbing.NewHashReference(plumbing.NewBranchReferenceName(change.Base), base.Hash),
+		)
+		if err != nil {
+			return err
+		}
+
+		change.MergeBase = head.Hash.String()
+		change.Merged = true
+		change.State = ChangeRequestClosed
+		return nil
+	})
+}
+
+func (store *LocalStore) Comment(ctx context.Context, repo string, index int64, body string) (int64, error) {
+	return store.addNote(repo, index, localNote{Body: body})
+}
+
+func (store *LocalStore) Review(ctx context.Context, repo string, index int64, state ReviewState, body string) (int64, error) {
+	return store.addNote(repo, index, localNote{Review: state, Body: body})
+}
+
+func (store *LocalStore) FindComment(ctx context.Context, repo string, index int64, marker string) (int64, error) {
+	return store.findNote(repo, index, false, marker)
+}
+
+func (store *LocalStore) FindReview(ctx context.Context, repo string, index int64, marker string) (int64, error) {
+	return store.findNote(repo, index, true, marker)
+}
+
+// findNote returns the first comment, or review, of the change request whose body ends with the marker
+func (store *LocalStore) findNote(repo string, index int64, review bool, marker string) (int64, error) {
+	_, changes, err := store.readChanges(repo)
+	if err != nil {
+		return 0, err
+	}
+	change := changes.find(index)
+	if change == nil {
+		return 0, ErrNotFound
+	}
+	for _, note := range change.Notes {
+		if (note.Review != "") == review && strings.HasSuffix(strings.TrimSpace(note.Body), marker) {
+			return note.ID, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+// addNote saves a comment or a review on the change request
+func (store *LocalStore) addNote(repo string, index int64, note localNote) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	err := store.updateChanges(repo, func(repository *git.Repository, changes *localChanges) error {
+		change := changes.find(index)
+		if change == nil {
+			return ErrNotFound
+		}
+		changes.LastNoteID++
+		note.ID = changes.LastNoteID
+		note.CreatedAt = time.Now()
+		change.Notes = append(change.Notes, note)
+		return nil
+	})
+	return note.ID, err
+}
+
+// readChanges opens the repository and reads its change requests
+func (store *LocalStore) readChanges(repo string) (*git.Repository, *localChanges, error) {
+	repository, err := store.open(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := store.repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := &localChanges{}
+	data, err := os.ReadFile(filepath.Join(path, changesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return repository, changes, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(data, changes); err != nil {
+		return nil, nil, err
+	}
+	return repository, changes, nil
+}
+
+// updateChanges reads the change requests, lets update modify them and saves them back, the mutex must be held
+func (store *LocalStore) updateChanges(repo string, update func(repository *git.Repository, changes *localChanges) error) error {
+	repository, changes, err := store.readChanges(repo)
+	if err != nil {
+		return err
+	}
+	if err := update(repository, changes); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	path, err := store.repoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	// Replace the file in one step so a crash never leaves it half written
+	temporary := filepath.Join(path, changesFile+".tmp")
+	if err := os.WriteFile(temporary, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, filepath.Join(path, changesFile))
+}
+
+// find returns the change request with the index
+func (changes *localChanges) find(index int64) *localChange {
+	for i := range changes.Changes {
+		if changes.Changes[i].Index == index {
+			return &changes.Changes[i]
+		}
+	}
+	return nil
+}
+
+func (change localChange) toChangeRequest() ChangeRequest {
+	return ChangeRequest{
+		Index:     change.Index,
+		Title:     change.Title,
+		Head:      change.Head,
+		Base:      change.Base,
+		MergeBase: change.MergeBase,
+		State:     change.State,
+		Merged:    change.Merged,
+	}
+}
+
+// currentChangeRequest converts the change request, the merge base of an open one follows its branches
+func currentChangeRequest(repository *git.Repository, change localChange) ChangeRequest {
+	changeRequest := change.toChangeRequest()
+	if change.State == ChangeRequestOpen {
+		if mergeBase, err := branchMergeBase(repository, change.Head, change.Base); err == nil {
+			changeRequest.MergeBase = mergeBase
+		}
+	}
+	return changeRequest
+}
+
+// branchMergeBase returns the best common ancestor of two branches
+func branchMergeBase(repository *git.Repository, head, base string) (string, error) {
+	headCommit, err := branchCommit(repository, head)
+	if err != nil {
+		return "", err
+	}
+	baseCommit, err := branchCommit(repository, base)
+	if err != nil {
+		return "", err
+	}
+
+	mergeBases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return "", err
+	}
+	if len(mergeBases) == 0 {
+		return "", fmt.Errorf("branches %s and %s have no common history", head, base)
+	}
+	return mergeBases[0].Hash.String(), nil
+}
+
+// mergeTrees returns the tree merging head into base, a file changed differently on both sides is a conflict
+func mergeTrees(repository *git.Repository, base, head *object.Commit) (plumbing.Hash, error) {
+	mergeBases, err := head.MergeBase(base)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if len(mergeBases) == 0 {
+		return plumbing.ZeroHash, fmt.Errorf("branches have no common history")
+	}
+	if mergeBases[0].Hash == base.Hash {
+		return head.TreeHash, nil
+	}
+
+	ancestorFiles, err := treeFiles(mergeBases[0])
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	baseFiles, err := treeFiles(base)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	headFiles, err := treeFiles(head)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	paths := map[string]bool{}
+	for _, files := range []map[string]plumbing.Hash{ancestorFiles, baseFiles, headFiles} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	merged := map[string]plumbing.Hash{}
+	for path := range paths {
+		ancestorHash, inAncestor := ancestorFiles[path]
+		baseHash, inBase := baseFiles[path]
+		headHash, inHead := headFiles[path]
+
+		var hash plumbing.Hash
+		var exists bool
+		switch {
+		case inBase == inHead && baseHash == headHash:
+			hash, exists = baseHash, inBase
+		case inAncestor == inBase && ancestorHash == baseHash:
+			hash, exists = headHash, inHead
+		case inAncestor == inHead && ancestorHash == headHash:
+			hash, exists = baseHash, inBase
+		default:
+			return plumbing.ZeroHash, fmt.Errorf("merge conflict in %s", path)
+		}
+		if exists {
+			merged[path] = hash
+		}
+	}
+
+	return writeTree(repository, merged)
+}
@@ -0,0 +1,402 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// LocalStore keeps the repositories as bare git repositories on disk,
+// change requests and their comments are saved next to the git data of each repository
+type LocalStore struct {
+	root string
+	// mutex serializes the writes, a single process owns the directory
+	mutex sync.Mutex
+}
+
+// NewLocalStore keeps the repositories in the root directory, creating it when needed
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("CONTENT_STORE_PATH is required by the local content store")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
+
+func (store *LocalStore) CreateRepo(ctx context.Context, repo, defaultBranch string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	path, err := store.repoPath(repo)
+	if err != nil {
+		return err
+	}
+	repository, err := git.PlainInitWithOptions(path, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(defaultBranch)},
+		Bare:        true,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Start with an empty commit so the default branch exists, like an auto initialized Gitea repository
+	treeHash, err := writeTree(repository, map[string]plumbing.Hash{})
+	if err != nil {
+		return err
+	}
+	commitHash, err := writeCommit(repository, treeHash, nil, "Initial commit", Identity{Name: "InstructHub"})
+	if err != nil {
+		return err
+	}
+	return repository.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(defaultBranch), commitHash))
+}
+
+func (store *LocalStore) RepoExists(ctx context.Context, repo string) (bool, error) {
+	_, err := store.open(repo)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (store *LocalStore) ListRepos(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(store.root)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), ".git") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".git"))
+		}
+	}
+	return names, nil
+}
+
+func (store *LocalStore) ReadFile(ctx context.Context, repo, ref, path string) ([]byte, error) {
+	repository, err := store.open(repo)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := resolveTree(repository, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := tree.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents), nil
+}
+
+func (store *LocalStore) ListFiles(ctx context.Context, repo, ref string) ([]FileInfo, error) {
+	repository, err := store.open(repo)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := resolveTree(repository, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []FileInfo{}
+	for _, entry := range tree.Entries {
+		if entry.Mode.IsFile() {
+			files = append(files, FileInfo{Path: entry.Name, SHA: entry.Hash.String()})
+		}
+	}
+	return files, nil
+}
+
+func (store *LocalStore) GetBranch(ctx context.Context, repo, branch string) (Commit, error) {
+	repository, err := store.open(repo)
+	if err != nil {
+		return Commit{}, err
+	}
+	commit, err := branchCommit(repository, branch)
+	if err != nil {
+		return Commit{}, err
+	}
+	return toCommit(commit), nil
+}
+
+func (store *LocalStore) DeleteBranch(ctx context.Context, repo, branch string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	repository, err := store.open(repo)
+	if err != nil {
+		return err
+	}
+	if _, err := branchCommit(repository, branch); err != nil {
+		return err
+	}
+	return repository.Storer.RemoveReference(plumbing.NewBranchReferenceName(branch))
+}
+
+func (store *LocalStore) CommitFiles(ctx context.Context, repo string, request CommitRequest) (Commit, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	repository, err := store.open(repo)
+	if err != nil {
+		return Commit{}, err
+	}
+	parent, err := branchCommit(repository, request.Branch)
+	if err != nil {
+		return Commit{}, err
+	}
+
+	target := request.Branch
+	if request.NewBranch != "" {
+		target = request.NewBranch
+		if _, err := branchCommit(repository, target); err == nil {
+			return Commit{}, fmt.Errorf("branch %s already exists", target)
+		} else if !errors.Is(err, ErrNotFound) {
+			return Commit{}, err
+		}
+	}
+
+	files, err := treeFiles(parent)
+	if err != nil {
+		return Commit{}, err
+	}
+	for _, file := range request.Files {
+		_, exists := files[file.Path]
+		switch file.Operation {
+		case OperationCreate:
+			if exists {
+				return Commit{}, fmt.Errorf("file %s already exists", file.Path)
+			}
+		case OperationUpdate, OperationDelete:
+			if !exists {
+				return Commit{}, fmt.Errorf("file %s doesn't exist", file.Path)
+			}
+		default:
+			return Commit{}, fmt.Errorf("unknown operation %q", file.Operation)
+		}
+
+		if file.Operation == OperationDelete {
+			delete(files, file.Path)
+			continue
+		}
+		hash, err := writeBlob(repository, file.Content)
+		if err != nil {
+			return Commit{}, err
+		}
+		files[file.Path] = hash
+	}
+
+	treeHash, err := writeTree(repository, files)
+	if err != nil {
+		return Commit{}, err
+	}
+	commitHash, err := writeCommit(repository, treeHash, []plumbing.Hash{parent.Hash}, request.Message, request.Author)
+	if err != nil {
+		return Commit{}, err
+	}
+
+	// Only move the branch when nobody committed on it in the meantime
+	newReference := plumbing.NewHashReference(plumbing.NewBranchReferenceName(target), commitHash)
+	var oldReference *plumbing.Reference
+	if request.NewBranch == "" {
+		oldReference = plumbing.NewHashReference(plumbing.NewBranchReferenceName(target), parent.Hash)
+	}
+	if err := repository.Storer.CheckAndSetReference(newReference, oldReference); err != nil {
+		return Commit{}, err
+	}
+
+	commit, err := repository.CommitObject(commitHash)
+	if err != nil {
+		return Commit{}, err
+	}
+	return toCommit(commit), nil
+}
+
+// Ping checks the root directory still exists
+func (store *LocalStore) Ping(ctx context.Context) error {
+	info, err := os.Stat(store.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", store.root)
+	}
+	return nil
+}
+
+// open opens the bare repository
+func (store *LocalStore) open(repo string) (*git.Repository, error) {
+	path, err := store.repoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+	repository, err := git.PlainOpen(path)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, ErrNotFound
+	}
+	return repository, err
+}
+
+// repoPath returns the directory of the repository, the name can't leave the root directory
+func (store *LocalStore) repoPath(repo string) (string, error) {
+	if repo == "" || repo == "." || repo == ".." || strings.ContainsAny(repo, `/\`) {
+		return "", fmt.Errorf("invalid repository name %q", repo)
+	}
+	return filepath.Join(store.root, repo+".git"), nil
+}
+
+// branchCommit returns the last commit of the branch
+func branchCommit(repository *git.Repository, branch string) (*object.Commit, error) {
+	reference, err := repository.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return repository.CommitObject(reference.Hash())
+}
+
+// resolveCommit returns the commit of a branch name or commit SHA
+func resolveCommit(repository *git.Repository, ref string) (*object.Commit, error) {
+	if !plumbing.IsHash(ref) {
+		return branchCommit(repository, ref)
+	}
+	commit, err := repository.CommitObject(plumbing.NewHash(ref))
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	return commit, err
+}
+
+// resolveTree returns the tree of a branch name or commit SHA
+func resolveTree(repository *git.Repository, ref string) (*object.Tree, error) {
+	commit, err := resolveCommit(repository, ref)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// treeFiles returns the blob of every file of the commit by path
+func treeFiles(commit *object.Commit) (map[string]plumbing.Hash, error) {
+	files := map[string]plumbing.Hash{}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	err = tree.Files().ForEach(func(file *object.File) error {
+		files[file.Name] = file.Hash
+		return nil
+	})
+	return files, err
+}
+
+// writeBlob saves the content as a blob
+func writeBlob(repository *git.Repository, content []byte) (plumbing.Hash, error) {
+	encoded := repository.Storer.NewEncodedObject()
+	encoded.SetType(plumbing.BlobObject)
+	writer, err := encoded.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := writer.Write(content); err != nil {
+		writer.Close()
+		return plumbing.ZeroHash, err
+	}
+	if err := writer.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repository.Storer.SetEncodedObject(encoded)
+}
+
+// writeTree saves the trees holding the files, paths can contain directories
+func writeTree(repository *git.Repository, files map[string]plumbing.Hash) (plumbing.Hash, error) {
+	tree := object.Tree{}
+	directories := map[string]map[string]plumbing.Hash{}
+
+	for path, hash := range files {
+		name, rest, isDirectory := strings.Cut(path, "/")
+		if !isDirectory {
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: hash})
+			continue
+		}
+		if directories[name] == nil {
+			directories[name] = map[string]plumbing.Hash{}
+		}
+		directories[name][rest] = hash
+	}
+	for name, directoryFiles := range directories {
+		hash, err := writeTree(repository, directoryFiles)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Dir, Hash: hash})
+	}
+
+	// Git sorts directories as if their name ended with a slash
+	sortName := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+		return entry.Name
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return sortName(tree.Entries[i]) < sortName(tree.Entries[j])
+	})
+
+	encoded := repository.Storer.NewEncodedObject()
+	if err := tree.Encode(encoded); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repository.Storer.SetEncodedObject(encoded)
+}
+
+// writeCommit saves a commit of the tree
+func writeCommit(repository *git.Repository, treeHash plumbing.Hash, parents []plumbing.Hash, message string, author Identity) (plumbing.Hash, error) {
+	signature := object.Signature{Name: author.Name, Email: author.Email, When: time.Now()}
+	commit := object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+
+	encoded := repository.Storer.NewEncodedObject()
+	if err := commit.Encode(encoded); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repository.Storer.SetEncodedObject(encoded)
+}
+
+// toCommit converts a go-git commit
+func toCommit(commit *object.Commit) Commit {
+	parents := []string{}
+	for _, parent := range commit.ParentHashes {
+		parents = append(parents, parent.String())
+	}
+	return Commit{SHA: commit.Hash.String(), Message: commit.Message, Parents: parents}
+}
@@ -2,43 +2,43 @@ package db
 
 import (
 	"fmt"
-	"os"
 	"time"
 
-	"github.com/instructhub/backend/pkg/logger"
+	"github.com/instructhub/backend/pkg/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// GORM DB Client
-var DB *gorm.DB
-
-// InitPostgres initializes the PostgreSQL connection
-func init() {
-	// Set connection options
-
-	host := os.Getenv("DATABASE_HOST")
-	user := os.Getenv("DATABASE_USER")
-	password := os.Getenv("DATABASE_PASSWORD")
-	dbname := os.Getenv("DATABASE_DBNAME")
-	port := os.Getenv("DATABASE_PORT")
-	sslmode := os.Getenv("DATABASE_SSLMODE")
+// Config holds the PostgreSQL connection settings
+type Config struct {
+	Host     string
+	User     string
+	Password string
+	DBName   string
+	Port     string
+	SSLMode  string
+}
 
+// Connect opens the PostgreSQL connection and checks the server answers
+func Connect(config Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		host, user, password, dbname, port, sslmode,
+		config.Host, config.User, config.Password, config.DBName, config.Port, config.SSLMode,
 	)
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		logger.Log.Sugar().Fatalf("Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	// Trace the statements, they are children of the context given with WithContext
+	if err := database.Use(tracing.GormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to trace database: %w", err)
 	}
 
 	// Set up connection pool
-	sqlDB, err := DB.DB()
+	sqlDB, err := database.DB()
 	if err != nil {
-		logger.Log.Sugar().Fatalf("Failed to get SQL DB instance: %v", err)
+		return nil, fmt.Errorf("failed to get SQL DB instance: %w", err)
 	}
 
 	sqlDB.SetMaxIdleConns(10)                  // Set the maximum number of idle connections
@@ -47,26 +47,18 @@ func init() {
 
 	// Check connection
 	if err = sqlDB.Ping(); err != nil {
-		logger.Log.Sugar().Fatal("Failed to ping PostgreSQL:", err)
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
 	}
 
-	logger.Log.Info("Successfully connected to PostgreSQL")
+	return database, nil
 }
 
-// GetDB returns the GORM DB instance
-func GetDB() *gorm.DB {
-	return DB
-}
-
-// ClosePostgres closes the PostgreSQL connection
-func ClosePostgres() {
-	sqlDB, err := DB.DB()
+// Close closes the PostgreSQL connection
+func Close(database *gorm.DB) error {
+	sqlDB, err := database.DB()
 	if err != nil {
-		logger.Log.Sugar().Fatal("Failed to get SQL DB instance:", err)
-	}
-
-	if err := sqlDB.Close(); err != nil {
-		logger.Log.Sugar().Fatal("Failed to close PostgreSQL connection:", err)
+		return fmt.Errorf("failed to get SQL DB instance: %w", err)
 	}
-	logger.Log.Info("Successfully disconnected to PostgreSQL")
+	return sqlDB.Close()
 }
@@ -0,0 +1,166 @@
+package diff
+
+import "strings"
+
+type Operation string
+
+const (
+	OperationEqual  Operation = "equal"
+	OperationInsert Operation = "insert"
+	OperationDelete Operation = "delete"
+)
+
+// Line is a single line of a line based diff
+type Line struct {
+	Operation Operation `json:"operation"`
+	Text      string    `json:"text"`
+}
+
+// maxEditDistance limits the work done on two very different inputs,
+// above it the whole old text is reported as deleted and the new one as inserted
+const maxEditDistance = 1000
+
+// Text compares two texts line by line
+func Text(oldText, newText string) []Line {
+	return Strings(SplitLines(oldText), SplitLines(newText))
+}
+
+// SplitLines splits a text into lines without the trailing empty line
+func SplitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Strings computes the shortest edit script between two string slices with the Myers algorithm
+func Strings(a, b []string) []Line {
+	// Common prefix and suffix don't need to go through the algorithm
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Operation: OperationEqual, Text: text})
+	}
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Operation: OperationEqual, Text: text})
+	}
+	return lines
+}
+
+// LongestCommonSubsequence returns the longest sequence of elements present in both slices in the same order
+func LongestCommonSubsequence(a, b []string) []string {
+	common := []string{}
+	for _, line := range Strings(a, b) {
+		if line.Operation == OperationEqual {
+			common = append(common, line.Text)
+		}
+	}
+	return common
+}
+
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return []Line{}
+	}
+
+	// v is indexed by the diagonal k from -(n+m)-1 to n+m+1
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// Only the diagonals step d can reach are kept for the backtrack, from -d-1 to d+1,
+	// so the trace grows with the edit distance and not with the size of the inputs
+	trace := [][]int{}
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEditDistance {
+			return replaceAll(a, b)
+		}
+
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	return replaceAll(a, b)
+}
+
+// backtrack walks the recorded trace from the end to build the edit script,
+// the diagonal k of step d is at k+d+1 in its snapshot
+func backtrack(trace [][]int, a, b []string) []Line {
+	x, y := len(a), len(b)
+	lines := []Line{}
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Operation: OperationEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				lines = append(lines, Line{Operation: OperationInsert, Text: b[y-1]})
+			} else {
+				lines = append(lines, Line{Operation: OperationDelete, Text: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	// The script was built from the end
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+func replaceAll(a, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, text := range a {
+		lines = append(lines, Line{Operation: OperationDelete, Text: text})
+	}
+	for _, text := range b {
+		lines = append(lines, Line{Operation: OperationInsert, Text: text})
+	}
+	return lines
+}
@@ -0,0 +1,183 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// apply rebuilds both sides of an edit script
+func apply(lines []Line) (old []string, new []string) {
+	old, new = []string{}, []string{}
+	for _, line := range lines {
+		if line.Operation != OperationInsert {
+			old = append(old, line.Text)
+		}
+		if line.Operation != OperationDelete {
+			new = append(new, line.Text)
+		}
+	}
+	return old, new
+}
+
+// numbered returns count lines made of the prefix and their index
+func numbered(prefix string, count int) []string {
+	lines := make([]string, count)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return lines
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []Line
+	}{
+		{
+			name: "both empty",
+			a:    []string{},
+			b:    []string{},
+			want: []Line{},
+		},
+		{
+			name: "equal",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: []Line{{OperationEqual, "a"}, {OperationEqual, "b"}},
+		},
+		{
+			name: "insert in the middle",
+			a:    []string{"a", "c"},
+			b:    []string{"a", "b", "c"},
+			want: []Line{{OperationEqual, "a"}, {OperationInsert, "b"}, {OperationEqual, "c"}},
+		},
+		{
+			name: "delete at the end",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "b"},
+			want: []Line{{OperationEqual, "a"}, {OperationEqual, "b"}, {OperationDelete, "c"}},
+		},
+		{
+			name: "replace a line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "x", "c"},
+			want: []Line{{OperationEqual, "a"}, {OperationDelete, "b"}, {OperationInsert, "x"}, {OperationEqual, "c"}},
+		},
+		{
+			name: "from nothing",
+			a:    []string{},
+			b:    []string{"a", "b"},
+			want: []Line{{OperationInsert, "a"}, {OperationInsert, "b"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Strings(test.a, test.b)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Strings(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+			}
+		})
+	}
+}
+
+func TestStringsEditDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		// equal is the number of lines the script keeps
+		equal int
+	}{
+		{
+			name:  "under the limit",
+			a:     append(append(numbered("a", 400), "common"), numbered("c", 50)...),
+			b:     append(append(numbered("b", 400), "common"), numbered("d", 50)...),
+			equal: 1,
+		},
+		{
+			name:  "over the limit",
+			a:     append(append(numbered("a", 600), "common"), numbered("c", 50)...),
+			b:     append(append(numbered("b", 600), "common"), numbered("d", 50)...),
+			equal: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := Strings(test.a, test.b)
+
+			old, new := apply(lines)
+			if !reflect.DeepEqual(old, test.a) || !reflect.DeepEqual(new, test.b) {
+				t.Fatalf("the edit script doesn't rebuild both sides")
+			}
+
+			equal := 0
+			for _, line := range lines {
+				if line.Operation == OperationEqual {
+					equal++
+				}
+			}
+			if equal != test.equal {
+				t.Errorf("got %d equal lines, want %d", equal, test.equal)
+			}
+		})
+	}
+}
+
+func TestStringsLargeInputsMemory(t *testing.T) {
+	a, b := numbered("a", 20000), numbered("b", 20000)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	lines := Strings(a, b)
+	runtime.ReadMemStats(&after)
+
+	if len(lines) != len(a)+len(b) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(a)+len(b))
+	}
+	// The trace only keeps the diagonals each step reaches, about 8 MB at the edit distance limit
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("allocated %d MB, want at most 64 MB", allocated>>20)
+	}
+}
+
+func TestStringsRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		lines := Strings(a, b)
+
+		old, new := apply(lines)
+		if !reflect.DeepEqual(old, a) || !reflect.DeepEqual(new, b) {
+			t.Fatalf("the edit script of %q and %q doesn't rebuild both sides", a, b)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	got := Text("a\nb\n", "a\nc\n")
+	want := []Line{{OperationEqual, "a"}, {OperationDelete, "b"}, {OperationInsert, "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Text() = %v, want %v", got, want)
+	}
+}
+
+func TestLongestCommonSubsequence(t *testing.T) {
+	got := LongestCommonSubsequence([]string{"a", "b", "c", "d"}, []string{"b", "x", "d", "a"})
+	want := []string{"b", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LongestCommonSubsequence() = %q, want %q", got, want)
+	}
+}
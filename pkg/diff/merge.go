@@ -0,0 +1,103 @@
+package diff
+
+import "strings"
+
+// Conflict is a region changed differently on both sides of a three-way merge
+type Conflict struct {
+	// Position of the conflicting region in the merged output
+	Position int      `json:"position"`
+	Base     []string `json:"base"`
+	Ours     []string `json:"ours"`
+	Theirs   []string `json:"theirs"`
+}
+
+// Merge3 merges the changes made from base to ours and from base to theirs with the diff3 algorithm,
+// conflicting regions keep the ours side in the merged output
+func Merge3(base, ours, theirs []string) ([]string, []Conflict) {
+	oursMatch := matchLines(base, ours)
+	theirsMatch := matchLines(base, theirs)
+
+	merged := make([]string, 0, len(ours)+len(theirs))
+	conflicts := []Conflict{}
+
+	i, j, k := 0, 0, 0
+	for i < len(base) || j < len(ours) || k < len(theirs) {
+		// Copy the lines all three sides agree on
+		for i < len(base) && oursMatch[i] == j && theirsMatch[i] == k {
+			merged = append(merged, base[i])
+			i, j, k = i+1, j+1, k+1
+		}
+		if i >= len(base) && j >= len(ours) && k >= len(theirs) {
+			break
+		}
+
+		// Find the next base line kept by both sides, everything before it is a changed chunk
+		nextI, nextJ, nextK := len(base), len(ours), len(theirs)
+		for next := i; next < len(base); next++ {
+			if oursMatch[next] >= j && theirsMatch[next] >= k {
+				nextI, nextJ, nextK = next, oursMatch[next], theirsMatch[next]
+				break
+			}
+		}
+
+		baseChunk, oursChunk, theirsChunk := base[i:nextI], ours[j:nextJ], theirs[k:nextK]
+		switch {
+		case equalLines(oursChunk, baseChunk):
+			merged = append(merged, theirsChunk...)
+		case equalLines(theirsChunk, baseChunk), equalLines(oursChunk, theirsChunk):
+			merged = append(merged, oursChunk...)
+		default:
+			conflicts = append(conflicts, Conflict{
+				Position: len(merged),
+				Base:     baseChunk,
+				Ours:     oursChunk,
+				Theirs:   theirsChunk,
+			})
+			merged = append(merged, oursChunk...)
+		}
+		i, j, k = nextI, nextJ, nextK
+	}
+
+	return merged, conflicts
+}
+
+// MergeText merges two texts changed from the same base line by line
+func MergeText(base, ours, theirs string) (string, []Conflict) {
+	merged, conflicts := Merge3(SplitLines(base), SplitLines(ours), SplitLines(theirs))
+	if len(merged) == 0 {
+		return "", conflicts
+	}
+	return strings.Join(merged, "\n") + "\n", conflicts
+}
+
+// matchLines maps every line of a to the index of the same line in b, or -1 when it was removed
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	x, y := 0, 0
+	for _, line := range Strings(a, b) {
+		switch line.Operation {
+		case OperationEqual:
+			match[x] = y
+			x++
+			y++
+		case OperationDelete:
+			match[x] = -1
+			x++
+		case OperationInsert:
+			y++
+		}
+	}
+	return match
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
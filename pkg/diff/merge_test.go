@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs []string
+		want               []string
+		conflicts          []Conflict
+	}{
+		{
+			name:   "unchanged",
+			base:   []string{"a", "b"},
+			ours:   []string{"a", "b"},
+			theirs: []string{"a", "b"},
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "edits in different places",
+			base:   []string{"a", "b", "c"},
+			ours:   []string{"A", "b", "c"},
+			theirs: []string{"a", "b", "C"},
+			want:   []string{"A", "b", "C"},
+		},
+		{
+			name:   "same edit on both sides",
+			base:   []string{"a", "b", "c"},
+			ours:   []string{"a", "x", "c"},
+			theirs: []string{"a", "x", "c"},
+			want:   []string{"a", "x", "c"},
+		},
+		{
+			name:      "conflicting edits",
+			base:      []string{"a", "b", "c"},
+			ours:      []string{"a", "x", "c"},
+			theirs:    []string{"a", "y", "c"},
+			want:      []string{"a", "x", "c"},
+			conflicts: []Conflict{{Position: 1, Base: []string{"b"}, Ours: []string{"x"}, Theirs: []string{"y"}}},
+		},
+		{
+			name:      "edit and delete",
+			base:      []string{"a", "b", "c"},
+			ours:      []string{"a", "x", "c"},
+			theirs:    []string{"a", "c"},
+			want:      []string{"a", "x", "c"},
+			conflicts: []Conflict{{Position: 1, Base: []string{"b"}, Ours: []string{"x"}, Theirs: []string{}}},
+		},
+		{
+			name:   "reorder and delete",
+			base:   []string{"a", "b", "c", "d"},
+			ours:   []string{"b", "a", "c", "d"},
+			theirs: []string{"a", "b", "c"},
+			want:   []string{"b", "a", "c"},
+		},
+		{
+			name:   "additions in different places",
+			base:   []string{"a", "b", "c"},
+			ours:   []string{"x", "a", "b", "c"},
+			theirs: []string{"a", "b", "c", "y"},
+			want:   []string{"x", "a", "b", "c", "y"},
+		},
+		{
+			name:      "additions in the same place",
+			base:      []string{"a", "b"},
+			ours:      []string{"a", "x", "b"},
+			theirs:    []string{"a", "y", "b"},
+			want:      []string{"a", "x", "b"},
+			conflicts: []Conflict{{Position: 1, Base: []string{}, Ours: []string{"x"}, Theirs: []string{"y"}}},
+		},
+		{
+			name:   "from an empty base",
+			base:   []string{},
+			ours:   []string{"a"},
+			theirs: []string{},
+			want:   []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, conflicts := Merge3(test.base, test.ours, test.theirs)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("merged = %q, want %q", got, test.want)
+			}
+			if test.conflicts == nil {
+				test.conflicts = []Conflict{}
+			}
+			if !reflect.DeepEqual(conflicts, test.conflicts) {
+				t.Errorf("conflicts = %+v, want %+v", conflicts, test.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeText(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          int
+	}{
+		{name: "clean", base: "a\nb\nc\n", ours: "A\nb\nc\n", theirs: "a\nb\nC\n", want: "A\nb\nC\n"},
+		{name: "conflict", base: "a\n", ours: "b\n", theirs: "c\n", want: "b\n", conflicts: 1},
+		{name: "everything removed", base: "a\n", ours: "", theirs: "a\n", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, conflicts := MergeText(test.base, test.ours, test.theirs)
+			if got != test.want {
+				t.Errorf("merged = %q, want %q", got, test.want)
+			}
+			if len(conflicts) != test.conflicts {
+				t.Errorf("got %d conflicts, want %d", len(conflicts), test.conflicts)
+			}
+		})
+	}
+}
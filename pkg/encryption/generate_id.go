@@ -1,24 +1,14 @@
 package encryption
 
 import (
-	"os"
-	"strconv"
 	"time"
 
 	"github.com/godruoyi/go-snowflake"
-	"github.com/instructhub/backend/pkg/logger"
 )
 
-// Init snowflake to set MachineID and start time
-func init() {
-	machineID := os.Getenv("MACHINE_ID")
-	num, err := strconv.Atoi(machineID)
-
-	if err != nil {
-		logger.Log.Fatal("Error to init snowflake invalid MACHINE_ID")
-	}
-
-	snowflake.SetMachineID(uint16(num))
+// SetupSnowflake sets the MachineID and start time of the generated IDs
+func SetupSnowflake(machineID uint16) {
+	snowflake.SetMachineID(machineID)
 	snowflake.SetStartTime(time.Date(2024, 10, 24, 0, 0, 0, 0, time.UTC))
 }
 
@@ -6,8 +6,6 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/argon2"
@@ -26,11 +24,19 @@ var (
     ErrIncompatibleVersion = errors.New("incompatible version of argon2")
 )
 
+// hashParams are the argon2 parameters of the new hashes
+var hashParams = params{memory: 64 * 1024, iterations: 20, parallelism: 4}
+
+// SetHashParams sets the argon2 parameters of the new hashes, memory is in KiB
+func SetHashParams(memory, iterations uint32, parallelism uint8) {
+	hashParams.memory = memory
+	hashParams.iterations = iterations
+	hashParams.parallelism = parallelism
+}
+
 // Hash passsword with argon2i
 func HashPassword(password string) (string, error) {
-	memory, _ := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32)
-	iterations, _ := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32)
-	parallelism, _ := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 32)
+	memory, iterations, parallelism := hashParams.memory, hashParams.iterations, hashParams.parallelism
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
 		return "", err
@@ -2,20 +2,20 @@ package encryption
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/instructhub/backend/pkg/logger"
 )
 
 var JwtSecretKey = ""
 
-func init() {
-	JwtSecretKey = os.Getenv("JWT_SECRET_KEY")
-	if JwtSecretKey == "" {
-		logger.Log.Fatal("missing JWT secret key")
+// SetJwtSecretKey sets the key signing the JWT tokens
+func SetJwtSecretKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("missing JWT secret key")
 	}
+	JwtSecretKey = key
+	return nil
 }
 
 
@@ -0,0 +1,79 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrNoSecretKey is returned when encrypting or decrypting without a key set
+var ErrNoSecretKey = errors.New("missing secret encryption key")
+
+var secretKey []byte
+
+// SetSecretKey sets the key encrypting the secrets stored in the database, the base64 of 32 bytes.
+// An empty key leaves the encryption unavailable.
+func SetSecretKey(key string) error {
+	if key == "" {
+		secretKey = nil
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(decoded) != 32 {
+		return fmt.Errorf("the secret encryption key must be the base64 of 32 bytes")
+	}
+	secretKey = decoded
+	return nil
+}
+
+// HasSecretKey reports whether the secrets can be encrypted
+func HasSecretKey() bool {
+	return secretKey != nil
+}
+
+// EncryptSecret encrypts a secret with AES-GCM, the nonce is prepended to the result
+func EncryptSecret(plaintext string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret
+func DecryptSecret(encrypted string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("error decoding secret: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted secret too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	if secretKey == nil {
+		return nil, ErrNoSecretKey
+	}
+	block, err := aes.NewCipher(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
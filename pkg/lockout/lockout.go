@@ -0,0 +1,130 @@
+// Package lockout slows down and stops the password guessing on the login.
+//
+// The failed logins are counted by account and by IP within a window. After
+// FreeAttempts failures the account has to wait before the next attempt, the
+// wait doubling with each failure, and after MaxAttempts failures it is locked
+// for LockDuration. The accounts are keyed by their email so the unknown
+// addresses behave the same as the known ones.
+package lockout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxDelay caps the wait between two attempts before the lock
+const maxDelay = 30 * time.Second
+
+// Policy configures when the attempts are slowed down and stopped
+type Policy struct {
+	// FreeAttempts is how many failures are allowed before waiting between the attempts
+	FreeAttempts int
+	// MaxAttempts is how many failures lock the account
+	MaxAttempts  int
+	LockDuration time.Duration
+	// Window is how long the failures are remembered after the last one
+	Window time.Duration
+	// MaxIPAttempts is how many failures from an IP stop its attempts on every account until the window ends
+	MaxIPAttempts int
+}
+
+// Status tells whether an attempt may be made
+type Status struct {
+	// Locked is set while the account is locked
+	Locked bool
+	// RetryAfter is how long to wait before the next attempt, 0 when it may be made now
+	RetryAfter time.Duration
+}
+
+// Guard counts the failed logins in Redis
+type Guard struct {
+	client *redis.Client
+	policy Policy
+}
+
+// NewGuard creates a guard keeping its counters in client
+func NewGuard(client *redis.Client, policy Policy) *Guard {
+	return &Guard{client: client, policy: policy}
+}
+
+// Check tells whether an attempt on the account of email may be made from ip
+func (guard *Guard) Check(ctx context.Context, email, ip string) (Status, error) {
+	account := accountKey(email)
+	pipe := guard.client.Pipeline()
+	lock := pipe.PTTL(ctx, "login_lock:"+account)
+	delay := pipe.PTTL(ctx, "login_delay:"+account)
+	ipFailures := pipe.Get(ctx, "login_failures_ip:"+ip)
+	ipTTL := pipe.PTTL(ctx, "login_failures_ip:"+ip)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Status{}, err
+	}
+
+	if lock.Val() > 0 {
+		return Status{Locked: true, RetryAfter: lock.Val()}, nil
+	}
+	if delay.Val() > 0 {
+		return Status{RetryAfter: delay.Val()}, nil
+	}
+	if failures, err := ipFailures.Int(); err == nil && failures >= guard.policy.MaxIPAttempts && ipTTL.Val() > 0 {
+		return Status{RetryAfter: ipTTL.Val()}, nil
+	}
+	return Status{}, nil
+}
+
+// Fail counts a failed attempt, justLocked is set when it locked the account
+func (guard *Guard) Fail(ctx context.Context, email, ip string) (justLocked bool, err error) {
+	account := accountKey(email)
+	pipe := guard.client.TxPipeline()
+	failures := pipe.Incr(ctx, "login_failures:"+account)
+	pipe.Expire(ctx, "login_failures:"+account, guard.policy.Window)
+	pipe.Incr(ctx, "login_failures_ip:"+ip)
+	pipe.Expire(ctx, "login_failures_ip:"+ip, guard.policy.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	count := int(failures.Val())
+	switch {
+	case count >= guard.policy.MaxAttempts:
+		// The count starts over once the lock ends
+		pipe := guard.client.TxPipeline()
+		pipe.Set(ctx, "login_lock:"+account, 1, guard.policy.LockDuration)
+		pipe.Del(ctx, "login_failures:"+account, "login_delay:"+account)
+		_, err := pipe.Exec(ctx)
+		return err == nil, err
+	case count > guard.policy.FreeAttempts:
+		return false, guard.client.Set(ctx, "login_delay:"+account, 1, delay(count-guard.policy.FreeAttempts)).Err()
+	}
+	return false, nil
+}
+
+// Succeed forgets the failures of the account after a successful login
+func (guard *Guard) Succeed(ctx context.Context, email string) error {
+	account := accountKey(email)
+	return guard.client.Del(ctx, "login_failures:"+account, "login_delay:"+account).Err()
+}
+
+// Unlock lifts the lock of the account and forgets its failures
+func (guard *Guard) Unlock(ctx context.Context, email string) error {
+	account := accountKey(email)
+	return guard.client.Del(ctx, "login_lock:"+account, "login_failures:"+account, "login_delay:"+account).Err()
+}
+
+// delay is the wait after the nth failure past the free attempts, 1s doubling up to maxDelay
+func delay(n int) time.Duration {
+	if n > 6 {
+		return maxDelay
+	}
+	return min(time.Second<<(n-1), maxDelay)
+}
+
+// accountKey hashes the email to keep the addresses out of Redis
+func accountKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
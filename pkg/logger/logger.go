@@ -2,25 +2,21 @@ package logger
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-var Log *zap.Logger
-
-// Initialize the logger during package initialization
-func init() {
-	// Create the logger instance based on environment
-	Log = zap.Must(zap.NewProduction())
-	if os.Getenv("GIN_MODE") == "debug" {
-		Log = zap.Must(zap.NewDevelopment())
+// New creates the logger, debug makes it human readable
+func New(debug bool) (*zap.Logger, error) {
+	if debug {
+		return zap.NewDevelopment()
 	}
+	return zap.NewProduction()
 }
 
 // LogError handles error logging with context
-func LogError(c *gin.Context, err error, message string, extraFields map[string]interface{}) {
+func LogError(log *zap.Logger, c *gin.Context, err error, message string, extraFields map[string]interface{}) {
 	// Log the error with context information
 	fields := []zap.Field{
 		zap.String("error", fmt.Sprintf("%v", err)),
@@ -35,5 +31,5 @@ func LogError(c *gin.Context, err error, message string, extraFields map[string]
 	}
 
 	// Log the error
-	Log.Error(message, fields...)
-}
\ No newline at end of file
+	log.Error(message, fields...)
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import "github.com/instructhub/backend/pkg/metrics"
+
+// instrumentedMailer counts the sent and the failed emails
+type instrumentedMailer struct {
+	next Mailer
+}
+
+// Instrument counts the emails sent through mailer
+func Instrument(mailer Mailer) Mailer {
+	return &instrumentedMailer{next: mailer}
+}
+
+func (mailer *instrumentedMailer) Send(to, subject, body string) error {
+	err := mailer.next.Send(to, subject, body)
+	if err != nil {
+		metrics.Emails.WithLabelValues("failed").Inc()
+	} else {
+		metrics.Emails.WithLabelValues("sent").Inc()
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"fmt"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Mailer sends the emails of the application
+type Mailer interface {
+	// Send sends an HTML email
+	Send(to, subject, body string) error
+}
+
+// SMTPOptions configures an SMTPMailer
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Key      string
+	From     string
+}
+
+// SMTPMailer sends the emails through an SMTP server
+type SMTPMailer struct {
+	dialer *gomail.Dialer
+	from   string
+}
+
+// NewSMTPMailer creates a mailer, the server is only contacted when sending
+func NewSMTPMailer(options SMTPOptions) *SMTPMailer {
+	return &SMTPMailer{
+		dialer: gomail.NewDialer(options.Host, options.Port, options.Username, options.Key),
+		from:   options.From,
+	}
+}
+
+func (mailer *SMTPMailer) Send(to, subject, body string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", mailer.from)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
+	if err := mailer.dialer.DialAndSend(m); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
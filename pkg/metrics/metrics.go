@@ -0,0 +1,131 @@
+// Package metrics holds the Prometheus metrics of the application, they are served by Handler
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "instructhub"
+
+// Registry holds every metric of the application, along with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// HTTP server
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent handling HTTP requests by route and method.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected by the rate limiter by policy.",
+	}, []string{"policy"})
+)
+
+// Users
+var (
+	Signups = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Created accounts by provider, email for the password signups.",
+	}, []string{"provider"})
+
+	Logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by provider and result.",
+	}, []string{"provider", "result"})
+
+	Emails = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_total",
+		Help:      "Emails by result, sent or failed.",
+	}, []string{"result"})
+)
+
+// Courses
+var (
+	RevisionsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "revisions_created_total",
+		Help:      "Created course revisions.",
+	})
+
+	RevisionsApproved = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "revisions_approved_total",
+		Help:      "Course revisions approved and merged.",
+	})
+
+	ImageUploads = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_uploads_total",
+		Help:      "Uploaded course images.",
+	})
+
+	ImageUploadBytes = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_upload_bytes_total",
+		Help:      "Size of the uploaded course images.",
+	})
+)
+
+// Outbound calls
+var (
+	ContentStoreDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "content_store_request_duration_seconds",
+		Help:      "Time spent in the calls to the content store, Gitea or local git, by operation and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation", "result"})
+
+	BlobStoreDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "blob_store_request_duration_seconds",
+		Help:      "Time spent in the calls to the blob store, S3 or local, by operation and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation", "result"})
+)
+
+// Result is the label of the outcome of an operation
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// ObserveCall records the duration of a call started at start
+func ObserveCall(histogram *prometheus.HistogramVec, backend, operation string, start time.Time, result string) {
+	histogram.WithLabelValues(backend, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves the metrics in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
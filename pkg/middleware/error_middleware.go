@@ -3,10 +3,11 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/instructhub/backend/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // ErrorLoggerMiddleware logs errors in all requests
-func ErrorLoggerMiddleware() gin.HandlerFunc {
+func ErrorLoggerMiddleware(log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
@@ -14,7 +15,7 @@ func ErrorLoggerMiddleware() gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			// Log each error that occurred during the request
 			for _, err := range c.Errors {
-				logger.LogError(c, err.Err, err.Error(), nil)
+				logger.LogError(log, c, err.Err, err.Error(), nil)
 			}
 		}
 	}
@@ -41,6 +41,14 @@ func IsAuthorized() gin.HandlerFunc {
 			return
 		}
 
+		// The token given between the password and the two-factor code isn't a login
+		_, ok = claims["mfa_pending"].(bool)
+		if ok {
+			utils.FullyResponse(c, 403, "Please enter the two-factor code first", utils.ErrUnauthorized, nil)
+			c.Abort()
+			return
+		}
+
 		// Add the user ID to the request context for further use
 		c.Set("userID", userID)
 		c.Next()
@@ -87,3 +95,33 @@ func IsPeddingVerify() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// IsMFAPending is a middleware to set the user ID of the mfa_pending cookie, given after the password
+// when the two-factor authentication is enabled
+func IsMFAPending() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cookie, err := c.Request.Cookie("mfa_pending")
+		if err != nil || cookie.Value == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := encryption.ParseAndValidateJWT(cookie.Value)
+		if err != nil {
+			c.Next()
+			return
+		}
+		if pending, ok := claims["mfa_pending"].(bool); !ok || !pending {
+			c.Next()
+			return
+		}
+		userIDFloat, ok := claims["sub"].(float64)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Set("userID", uint64(userIDFloat))
+		c.Next()
+	}
+}
@@ -4,12 +4,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/instructhub/backend/pkg/logger"
 	"go.uber.org/zap"
 )
 
 // CustomLogger is a middleware to log HTTP requests with execution time and status code
-func CustomLogger() gin.HandlerFunc {
+func CustomLogger(log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Start time
 		startTime := time.Now()
@@ -24,7 +23,7 @@ func CustomLogger() gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 
 		// Log the request details
-		logger.Log.Info("HTTP Request",
+		log.Info("HTTP Request",
 			zap.String("timestamp", time.Now().Format("2006/01/02 - 15:04:05")),
 			zap.Int("status_code", statusCode),
 			zap.String("method", c.Request.Method),
@@ -33,4 +32,4 @@ func CustomLogger() gin.HandlerFunc {
 			zap.String("path", c.Request.URL.Path),
 		)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
+	"github.com/instructhub/backend/app/queries"
+	"github.com/instructhub/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// RequireCourseRole is a middleware to check if the user has at least the given role on the course,
+// it must be used after IsAuthorized
+func RequireCourseRole(db *gorm.DB, role models.CourseRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		course, memberRole, isMember, ok := loadCourseRole(c, db)
+		if !ok {
+			return
+		}
+
+		if !isMember || memberRole < role {
+			utils.FullyResponse(c, 403, "You don't have permission to do this", utils.ErrPermissionDenied, nil)
+			c.Abort()
+			return
+		}
+
+		c.Set("course", course)
+		c.Set("courseRole", memberRole)
+		c.Next()
+	}
+}
+
+// RequireCourseAccess is a middleware to check if the user can contribute to the course,
+// everyone can contribute to a public course but only members can contribute to a private one
+func RequireCourseAccess(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		course, memberRole, isMember, ok := loadCourseRole(c, db)
+		if !ok {
+			return
+		}
+
+		if course.Private && !isMember {
+			utils.FullyResponse(c, 403, "Only course members can access this course", utils.ErrNotCourseMember, nil)
+			c.Abort()
+			return
+		}
+
+		c.Set("course", course)
+		if isMember {
+			c.Set("courseRole", memberRole)
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin is a middleware to check if the user is an admin, it must be used after IsAuthorized
+func RequireAdmin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+			c.Abort()
+			return
+		}
+
+		user, result := queries.GetUserQueueByID(db.WithContext(c), userID)
+		if result.Error == gorm.ErrRecordNotFound {
+			utils.FullyResponse(c, 403, "You don't have permission to do this", utils.ErrPermissionDenied, nil)
+			c.Abort()
+			return
+		} else if result.Error != nil {
+			utils.ServerErrorResponse(c, 500, "Error fetching user", utils.ErrGetData, result.Error)
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin {
+			utils.FullyResponse(c, 403, "You don't have permission to do this", utils.ErrPermissionDenied, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// loadCourseRole fetches the course from the URL and the role of the current user on it,
+// the response is already written when ok is false
+func loadCourseRole(c *gin.Context, db *gorm.DB) (course models.Course, role models.CourseRole, isMember bool, ok bool) {
+	courseID, err := utils.StrToUint64(c.Param("courseID"))
+	if err != nil {
+		utils.FullyResponse(c, 400, "Invalid course ID", utils.ErrBadRequest, nil)
+		c.Abort()
+		return course, role, false, false
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		c.Abort()
+		return course, role, false, false
+	}
+
+	course, result := queries.GetCourseInformation(db.WithContext(c), courseID)
+	if result.Error == gorm.ErrRecordNotFound {
+		utils.FullyResponse(c, 404, "Course not exist", utils.ErrCourseNotExist, nil)
+		c.Abort()
+		return course, role, false, false
+	} else if result.Error != nil {
+		utils.ServerErrorResponse(c, 500, "Error fetching course", utils.ErrGetData, result.Error)
+		c.Abort()
+		return course, role, false, false
+	}
+
+	member, result := queries.GetCourseMember(db.WithContext(c), courseID, userID)
+	if result.Error == nil {
+		return course, member.Role, true, true
+	} else if result.Error != gorm.ErrRecordNotFound {
+		utils.ServerErrorResponse(c, 500, "Error fetching course member", utils.ErrGetData, result.Error)
+		c.Abort()
+		return course, role, false, false
+	}
+
+	// Courses created before memberships existed only know their creator
+	if course.CreatorID == userID {
+		owners, result := queries.CountCourseMembersByRole(db.WithContext(c), courseID, models.CourseOwner)
+		if result.Error != nil {
+			utils.ServerErrorResponse(c, 500, "Error fetching course member", utils.ErrGetData, result.Error)
+			c.Abort()
+			return course, role, false, false
+		}
+		if owners == 0 {
+			return course, models.CourseOwner, true, true
+		}
+	}
+
+	return course, role, false, true
+}
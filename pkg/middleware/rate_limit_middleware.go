@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/pkg/metrics"
+	"github.com/instructhub/backend/pkg/ratelimit"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// maxKeyBodySize bounds how much of the body is read to find the email of a request
+const maxKeyBodySize = 64 << 10
+
+// RateLimitKey returns the key a request is counted under, the rule is skipped when it is empty
+type RateLimitKey func(c *gin.Context) string
+
+// RateLimitRule counts the requests under a policy by key
+type RateLimitRule struct {
+	Policy ratelimit.Policy
+	Key    RateLimitKey
+}
+
+// ByIP counts the requests by client IP
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByUserID counts the requests by the user ID set by IsAuthorized or IsPeddingVerify
+func ByUserID(c *gin.Context) string {
+	userID, ok := c.Get("userID")
+	if !ok {
+		return ""
+	}
+	return strconv.FormatUint(userID.(uint64), 10)
+}
+
+// ByEmail counts the requests by the email field of the JSON body, hashed to keep the addresses out of Redis
+func ByEmail(c *gin.Context) string {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxKeyBodySize))
+	if err != nil {
+		return ""
+	}
+	// The handler binds the body afterwards
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &request) != nil || request.Email == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(request.Email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// RateLimit is a middleware to reject the requests over the rate of one of the rules with 429.
+// The headers describe the most restrictive rule, following the RateLimit header fields draft.
+// The requests are let through when the limiter fails, or when it is nil.
+func RateLimit(limiter ratelimit.Limiter, rules ...RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		var tightest *ratelimit.Result
+		var denied *ratelimit.Result
+		var deniedPolicy ratelimit.Policy
+		for _, rule := range rules {
+			key := rule.Key(c)
+			if key == "" {
+				continue
+			}
+			result, err := limiter.Allow(c, rule.Policy, key)
+			if err != nil {
+				c.Error(err)
+				continue
+			}
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = &result
+			}
+			if !result.Allowed && (denied == nil || result.RetryAfter > denied.RetryAfter) {
+				denied = &result
+				deniedPolicy = rule.Policy
+			}
+		}
+
+		if denied != nil {
+			setRateLimitHeaders(c, *denied)
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(denied.RetryAfter)))
+			metrics.RateLimited.WithLabelValues(deniedPolicy.Name).Inc()
+			utils.FullyResponse(c, 429, "Too many requests, try again later", utils.ErrTooManyRequests, nil)
+			c.Abort()
+			return
+		}
+		if tightest != nil {
+			setRateLimitHeaders(c, *tightest)
+		}
+		c.Next()
+	}
+}
+
+func setRateLimitHeaders(c *gin.Context, result ratelimit.Result) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds rounds up so the clients don't retry before the limit allows it
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
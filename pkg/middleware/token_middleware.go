@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/pkg/utils"
+)
+
+// RequireBearerToken is a middleware to check the request is sent with the token, for the machine clients
+func RequireBearerToken(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			utils.FullyResponse(c, 401, "Invalid token", utils.ErrUnauthorized, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,44 +2,43 @@ package config
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/instructhub/backend/pkg/logger"
-	"github.com/instructhub/backend/pkg/utils"
-	"github.com/joho/godotenv"
+	"github.com/gorilla/sessions"
+	"github.com/instructhub/backend/pkg/config"
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
 	"github.com/markbates/goth/providers/github"
 	"github.com/markbates/goth/providers/gitlab"
 	"github.com/markbates/goth/providers/google"
 )
 
-// Init oauth for goth
-func init() {
-	err := godotenv.Load("template.env")
-	if err != nil {
-		logger.Log.Fatal("Error loading .env file")
-	}
+// UseProviders registers the OAuth providers in goth, backendURL is where their callbacks are served
+func UseProviders(cfg config.OAuthConfig, backendURL string) {
+	// Keep the state of the logins in a cookie signed with the session secret
+	store := sessions.NewCookieStore([]byte(cfg.SessionSecret))
+	store.Options.HttpOnly = true
+	gothic.Store = store
 
 	// Change your url
 	goth.UseProviders(
 		google.New(
-			os.Getenv("GOOGLE_CLIENT_ID"),
-			os.Getenv("GOOGLE_CLIENT_SECRET"),
-			fmt.Sprintf("%s/auth/oauth/google/callback", utils.BackendURL),
+			cfg.Google.ClientID,
+			cfg.Google.ClientSecret,
+			fmt.Sprintf("%s/auth/oauth/google/callback", backendURL),
 			"email",
 			"profile",
 		),
 
 		github.New(
-			os.Getenv("GITHUB_CLIENT_ID"),
-			os.Getenv("GITHUB_CLIENT_SECRET"),
-			fmt.Sprintf("%s/auth/oauth/github/callback", utils.BackendURL),
+			cfg.Github.ClientID,
+			cfg.Github.ClientSecret,
+			fmt.Sprintf("%s/auth/oauth/github/callback", backendURL),
 		),
 
 		gitlab.New(
-			os.Getenv("GITLAB_CLIENT_ID"),
-			os.Getenv("GITLAB_CLIENT_SECRET"),
-			fmt.Sprintf("%s/auth/oauth/gitlab/callback", utils.BackendURL),
+			cfg.Gitlab.ClientID,
+			cfg.Gitlab.ClientSecret,
+			fmt.Sprintf("%s/auth/oauth/gitlab/callback", backendURL),
 		),
 	)
 }
@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many requests are counted between two removals of the idle keys
+const sweepEvery = 1024
+
+// MemoryLimiter keeps the counters in memory, for the tests and the single instance setups
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	tats     map[string]time.Time
+	requests int
+}
+
+// NewMemoryLimiter creates an empty in-memory limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{tats: map[string]time.Time{}}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	limiter.requests++
+	if limiter.requests%sweepEvery == 0 {
+		limiter.sweep(now)
+	}
+
+	key = policy.Name + ":" + key
+	result, tat := gcra(policy, now, limiter.tats[key])
+	if result.Allowed {
+		limiter.tats[key] = tat
+	}
+	return result, nil
+}
+
+// sweep removes the keys whose burst is full again, they behave the same as unknown keys
+func (limiter *MemoryLimiter) sweep(now time.Time) {
+	for key, tat := range limiter.tats {
+		if !tat.After(now) {
+			delete(limiter.tats, key)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package ratelimit limits how often a key, like an IP or a user, may do something.
+//
+// The limiters implement GCRA: a key may do Limit requests per Period, spread
+// evenly, with bursts of up to Limit requests after staying idle. Only the
+// theoretical arrival time of the next request is stored per key.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy is the allowed rate of a kind of request
+type Policy struct {
+	// Name is part of the stored keys, it must be unique among the policies
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// emission is the time between two requests at the allowed rate
+func (policy Policy) emission() time.Duration {
+	return policy.Period / time.Duration(policy.Limit)
+}
+
+// Result is the decision for a request
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is how many more requests are allowed right now
+	Remaining int
+	// RetryAfter is how long to wait before the next request is allowed, 0 when allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the full burst is allowed again
+	ResetAfter time.Duration
+}
+
+// Limiter decides whether the requests of a key are allowed
+type Limiter interface {
+	// Allow counts a request of key under policy when it is allowed
+	Allow(ctx context.Context, policy Policy, key string) (Result, error)
+}
+
+// gcra decides a request arriving at now when the next request was expected at tat,
+// it returns the new tat, which is only stored when the request is allowed
+func gcra(policy Policy, now, tat time.Time) (Result, time.Time) {
+	emission := policy.emission()
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(emission)
+	allowAt := newTat.Add(-policy.Period)
+
+	diff := now.Sub(allowAt)
+	if diff < 0 {
+		return Result{Limit: policy.Limit, RetryAfter: -diff, ResetAfter: tat.Sub(now)}, tat
+	}
+	return Result{
+		Allowed:    true,
+		Limit:      policy.Limit,
+		Remaining:  int(diff / emission),
+		ResetAfter: newTat.Sub(now),
+	}, newTat
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix is the prefix of the keys stored by RedisLimiter
+const keyPrefix = "ratelimit:"
+
+// gcraScript is gcra run in Redis, with the clock of Redis so the instances agree on the time.
+// The times are in milliseconds, the tat expires once the burst is full again.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local emission = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tat = tonumber(redis.call("GET", key))
+if tat == nil or tat < now then
+  tat = now
+end
+local new_tat = tat + emission
+local diff = now - (new_tat - period)
+
+if diff < 0 then
+  return {0, 0, -diff, tat - now}
+end
+redis.call("SET", key, new_tat, "PX", new_tat - now)
+return {1, math.floor(diff / emission), 0, new_tat - now}
+`)
+
+// RedisLimiter keeps the counters in Redis, shared by the instances
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter creates a limiter storing its counters in client, the limiter database
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+func (limiter *RedisLimiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	values, err := gcraScript.Run(ctx, limiter.client,
+		[]string{keyPrefix + policy.Name + ":" + key},
+		policy.emission().Milliseconds(), policy.Period.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/instructhub/backend/pkg/metrics"
+	"github.com/instructhub/backend/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("github.com/instructhub/backend/pkg/storage")
+
+// instrumentedStore measures and traces every call to the store it wraps
+type instrumentedStore struct {
+	next    BlobStore
+	backend string
+}
+
+// Instrument records the duration of every call to store and starts a span for it, backend names it
+// in the metrics and the spans
+func Instrument(store BlobStore, backend string) BlobStore {
+	return &instrumentedStore{next: store, backend: backend}
+}
+
+// start starts the span of a call
+func (store *instrumentedStore) start(ctx context.Context, operation string) (context.Context, trace.Span, time.Time) {
+	ctx, span := tracing.StartChild(ctx, tracer, "storage."+operation, trace.WithAttributes(attribute.String("storage.backend", store.backend)))
+	return ctx, span, time.Now()
+}
+
+// observe records a call and ends its span
+func (store *instrumentedStore) observe(span trace.Span, operation string, start time.Time, err error) {
+	metrics.ObserveCall(metrics.BlobStoreDuration, store.backend, operation, start, metrics.Result(err))
+	tracing.End(span, err)
+}
+
+// Close closes the wrapped store when it holds resources
+func (store *instrumentedStore) Close() error {
+	if closer, ok := store.next.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (store *instrumentedStore) Put(ctx context.Context, key, contentType string, content []byte) error {
+	ctx, span, start := store.start(ctx, "put")
+	span.SetAttributes(attribute.String("storage.key", key), attribute.Int("storage.size", len(content)))
+	err := store.next.Put(ctx, key, contentType, content)
+	store.observe(span, "put", start, err)
+	return err
+}
+
+// URL only builds a string, it isn't measured
+func (store *instrumentedStore) URL(key string) string {
+	return store.next.URL(key)
+}
+
+func (store *instrumentedStore) Ping(ctx context.Context) error {
+	ctx, span, start := store.start(ctx, "ping")
+	err := store.next.Ping(ctx)
+	store.observe(span, "ping", start, err)
+	return err
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps the files on disk, the API serves them under LocalRoute
+type LocalStore struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStore keeps the files in the root directory, creating it when needed
+func NewLocalStore(root, baseURL string) (*LocalStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("STORAGE_PATH is required by the local storage")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root, baseURL: baseURL}, nil
+}
+
+// Root returns the directory the files are kept in
+func (store *LocalStore) Root() string {
+	return store.root
+}
+
+func (store *LocalStore) Put(ctx context.Context, key, contentType string, content []byte) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write next to the destination first so a file is never served half written
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+func (store *LocalStore) URL(key string) string {
+	return fmt.Sprintf("%s/%s", store.baseURL, key)
+}
+
+// Ping checks the root directory still exists
+func (store *LocalStore) Ping(ctx context.Context) error {
+	info, err := os.Stat(store.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", store.root)
+	}
+	return nil
+}
+
+// path returns where the file of key is kept, the key can't leave the root directory
+func (store *LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(store.root, cleaned), nil
+}
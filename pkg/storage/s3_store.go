@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/instructhub/backend/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+// S3Options configures an S3Store
+type S3Options struct {
+	Endpoint    string
+	AccessKeyID string
+	SecretKey   string
+	Bucket      string
+	// BaseURL is the public URL of the bucket
+	BaseURL string
+	// PathStyle is needed by MinIO
+	PathStyle bool
+	// TLSVerify can be turned off for a server with a self-signed certificate
+	TLSVerify bool
+	// CreateBucket creates a missing bucket and makes it publicly readable
+	CreateBucket bool
+}
+
+// S3Store keeps the files in a bucket of an S3 compatible server
+type S3Store struct {
+	client     *s3.Client
+	httpClient *http.Client
+	bucket     string
+	baseURL    string
+	log        *zap.Logger
+}
+
+// NewS3Store connects to the server and checks the bucket exists, log reports the bucket creation
+func NewS3Store(options S3Options, log *zap.Logger) (*S3Store, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(options.AccessKeyID, options.SecretKey, "")),
+		config.WithRegion("auto"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !options.TLSVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	// Every request is traced, the SDK calls show up as HTTP spans
+	httpClient := &http.Client{Transport: tracing.Transport(transport)}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(options.Endpoint)
+		o.HTTPClient = httpClient
+		o.UsePathStyle = options.PathStyle
+	})
+
+	store := &S3Store{client: client, httpClient: httpClient, bucket: options.Bucket, baseURL: options.BaseURL, log: log}
+	if err := store.ensureBucket(options.CreateBucket); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (store *S3Store) Put(ctx context.Context, key, contentType string, content []byte) error {
+	_, err := store.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &store.bucket,
+		Key:         &key,
+		Body:        bytes.NewReader(content),
+		ContentType: &contentType,
+	})
+	return err
+}
+
+func (store *S3Store) URL(key string) string {
+	return fmt.Sprintf("%s/%s", store.baseURL, key)
+}
+
+// Close closes the idle connections to the server
+func (store *S3Store) Close() error {
+	store.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// Ping checks the bucket can be reached with the credentials
+func (store *S3Store) Ping(ctx context.Context) error {
+	_, err := store.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: &store.bucket,
+	})
+	return err
+}
+
+// ensureBucket checks the bucket exists, creating it when allowed
+func (store *S3Store) ensureBucket(create bool) error {
+	_, err := store.client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
+		Bucket: &store.bucket,
+	})
+	if err == nil {
+		return nil
+	}
+
+	var notFoundErr *types.NotFound
+	if !errors.As(err, &notFoundErr) {
+		return fmt.Errorf("failed to check bucket %s: %w", store.bucket, err)
+	}
+	if !create {
+		return fmt.Errorf("bucket %s doesn't exist, create it or set S3_CREATE_BUCKET=true", store.bucket)
+	}
+
+	if _, err := store.client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
+		Bucket: &store.bucket,
+	}); err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", store.bucket, err)
+	}
+	store.log.Sugar().Infof("Bucket %s created successfully.", store.bucket)
+
+	// Set the bucket policy to make it publicly readable
+	policy := fmt.Sprintf(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Principal": "*",
+				"Action": "s3:GetObject",
+				"Resource": "arn:aws:s3:::%s/*"
+			}
+		]
+	}`, store.bucket)
+
+	if _, err := store.client.PutBucketPolicy(context.TODO(), &s3.PutBucketPolicyInput{
+		Bucket: &store.bucket,
+		Policy: &policy,
+	}); err != nil {
+		return fmt.Errorf("failed to set public read policy for bucket %s: %w", store.bucket, err)
+	}
+	store.log.Sugar().Infof("Public read policy set for bucket %s.", store.bucket)
+	return nil
+}
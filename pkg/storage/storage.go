@@ -0,0 +1,16 @@
+package storage
+
+import "context"
+
+// LocalRoute is the path, under the API, the local blob store is served from
+const LocalRoute = "/uploads"
+
+// BlobStore keeps the files uploaded by the users, like course images
+type BlobStore interface {
+	// Put saves the content under key, replacing what was there
+	Put(ctx context.Context, key, contentType string, content []byte) error
+	// URL returns the public URL of the file saved under key
+	URL(key string) string
+	// Ping checks the store can be reached
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey keeps the span of a statement between its before and after callbacks
+const spanKey = "tracing:span"
+
+// gormPlugin starts a span for the statements run in a trace, they are children of the span of
+// the context given with gorm.DB.WithContext. The statements without a trace, like the polling of
+// the workers, aren't traced.
+type gormPlugin struct {
+	tracer trace.Tracer
+}
+
+// GormPlugin traces the statements of a gorm.DB, register it with db.Use
+func GormPlugin() gorm.Plugin {
+	return &gormPlugin{tracer: Tracer("gorm.io/gorm")}
+}
+
+func (plugin *gormPlugin) Name() string {
+	return "tracing"
+}
+
+func (plugin *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", plugin.before("create")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", plugin.after),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", plugin.before("select")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", plugin.after),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", plugin.before("update")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", plugin.after),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", plugin.before("delete")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", plugin.after),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", plugin.before("row")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", plugin.after),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", plugin.before("raw")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", plugin.after),
+	)
+}
+
+// before starts the span of a statement
+func (plugin *gormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := "gorm." + operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := StartChild(db.Statement.Context, plugin.tracer, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// after ends the span of a statement with the SQL that ran, the values are left as placeholders
+func (plugin *gormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,148 @@
+// Package tracing sets up the OpenTelemetry tracing of the application.
+//
+// The spans are sent to an OTLP collector or printed on stdout, the trace
+// context is read from and written to the W3C traceparent header so the traces
+// continue across the services.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// Options configures the exporter of the spans
+type Options struct {
+	// Exporter is otlp, stdout or none
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	SampleRatio  float64
+	ServiceName  string
+	Version      string
+}
+
+// Setup installs the global tracer provider and propagator, the returned function flushes
+// the remaining spans and must be called before exiting
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.Exporter {
+	case "otlp":
+		exporterOptions := []otlptracehttp.Option{}
+		if options.OTLPEndpoint != "" {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithEndpoint(options.OTLPEndpoint))
+		}
+		if options.OTLPInsecure {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, exporterOptions...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "none", "":
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, errors.New("unknown tracing exporter " + options.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(options.ServiceName),
+		semconv.ServiceVersion(options.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of an instrumented package, named after its import path
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// StartChild starts a span when ctx is already in a trace, the calls made outside of any trace,
+// like the probes and the polling of the workers, aren't traced
+func StartChild(ctx context.Context, tracer trace.Tracer, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, name, options...)
+}
+
+// childTracerProvider hands out tracers following StartChild, for the libraries that can't filter what they trace
+type childTracerProvider struct {
+	embedded.TracerProvider
+}
+
+// ChildTracerProvider returns the global tracers wrapped to follow StartChild
+func ChildTracerProvider() trace.TracerProvider {
+	return childTracerProvider{}
+}
+
+func (childTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return childTracer{tracer: otel.Tracer(name, options...)}
+}
+
+type childTracer struct {
+	embedded.Tracer
+	tracer trace.Tracer
+}
+
+func (tracer childTracer) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return StartChild(ctx, tracer.tracer, name, options...)
+}
+
+// Transport traces the requests sent through base and sends the trace context along,
+// following StartChild
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base, otelhttp.WithFilter(func(request *http.Request) bool {
+		return trace.SpanContextFromContext(request.Context()).IsValid()
+	}))
+}
+
+// End ends the span, marking it failed when err is set
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the W3C trace context of ctx, to be saved with work done later
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract returns ctx with the trace context saved by Inject
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
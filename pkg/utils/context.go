@@ -3,7 +3,9 @@ package utils
 
 import (
 	"fmt"
+
 	"github.com/gin-gonic/gin"
+	"github.com/instructhub/backend/app/models"
 )
 
 // GetUserIDFromContext retrieves the user ID from the request context.
@@ -14,3 +16,12 @@ func GetUserIDFromContext(c *gin.Context) (uint64, error) {
 	}
 	return ContextUserID.(uint64), nil
 }
+
+// GetCourseRoleFromContext retrieves the course role set by the course permission middlewares.
+func GetCourseRoleFromContext(c *gin.Context) (models.CourseRole, bool) {
+	contextRole, exists := c.Get("courseRole")
+	if !exists {
+		return 0, false
+	}
+	return contextRole.(models.CourseRole), true
+}
@@ -5,21 +5,36 @@ const (
 	ErrAuthenticationKeyNotFound = "authentication_key_not_found"
 	ErrUnauthorized              = "unauthorized"
 	ErrTokenExpired              = "token_expired"
+	ErrPermissionDenied          = "permission_denied"
 )
 
 // Request errors
 const (
-	ErrBadRequest     = "bad_request"
-	ErrUserIDNotFound = "user_id_not_found"
+	ErrBadRequest      = "bad_request"
+	ErrUserIDNotFound  = "user_id_not_found"
+	ErrTooManyRequests = "too_many_requests"
 )
 
 // User-related errors
 const (
-	ErrInvalidUsernameOrEmail = "invalid_username_or_email"
-	ErrInvalidPassword        = "invalid_password"
-	ErrEmailAlreadyUsed       = "email_already_used"
-	ErrUsernameAlreadyUsed    = "username_already_used"
-	ErrEmailNotVerify         = "email_not_verify"
+	ErrInvalidCredentials   = "invalid_credentials"
+	ErrInvalidPassword      = "invalid_password"
+	ErrTooManyLoginAttempts = "too_many_login_attempts"
+	ErrInvalidResetToken    = "invalid_reset_token"
+	ErrEmailAlreadyUsed     = "email_already_used"
+	ErrUsernameAlreadyUsed  = "username_already_used"
+	ErrEmailNotVerify       = "email_not_verify"
+	ErrUserNotFound         = "user_not_found"
+
+	ErrPasswordNotSet           = "password_not_set"
+	ErrPasswordAlreadySet       = "password_already_set"
+	ErrReauthenticationRequired = "reauthentication_required"
+
+	ErrMFAUnavailable    = "mfa_unavailable"
+	ErrMFANotEnrolled    = "mfa_not_enrolled"
+	ErrMFAAlreadyEnabled = "mfa_already_enabled"
+	ErrMFANotEnabled     = "mfa_not_enabled"
+	ErrInvalidMFACode    = "invalid_mfa_code"
 )
 
 // Courses-releated errors
@@ -27,6 +42,12 @@ const (
 	ErrDuplicateCourseModule = "duplicate_courses_module"
 	ErrMissingCourseID       = "missing_course_id"
 	ErrCourseNotExist        = "course_not_exist"
+	ErrNotCourseMember       = "not_course_member"
+
+	ErrInvalidCourseRole   = "invalid_course_role"
+	ErrAlreadyCourseMember = "already_course_member"
+	ErrInvitationNotExist  = "invitation_not_exist"
+	ErrLastCourseOwner     = "last_course_owner"
 
 	ErrImageRequired      = "image_required"
 	ErrImageTooLarge      = "image_too_large"
@@ -39,6 +60,14 @@ const (
 
 	ErrRivisionNotExist = "revision_not_exist"
 	ErrAlreadyMerged    = "revision_already_merged"
+	ErrRevisionLocked   = "revision_locked"
+	ErrRevisionNotOpen  = "revision_not_open"
+	ErrCommentNotExist  = "comment_not_exist"
+
+	ErrInvalidRevisionStatus = "invalid_revision_status"
+	ErrRevisionBranchDeleted = "revision_branch_deleted"
+	ErrRevisionConflict      = "revision_conflict"
+	ErrRevisionNotReady      = "revision_not_ready"
 )
 
 // Database errors
@@ -68,3 +97,8 @@ const (
 	ErrCreateNewCourse = "create_new_course_failed"
 	ErrSaveCourseFile  = "save_new_course_file_failed"
 )
+
+// Health errors
+const (
+	ErrServiceUnavailable = "service_unavailable"
+)
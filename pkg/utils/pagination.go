@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// GetPagination reads the page and page_size query parameters, falling back to sane defaults
+func GetPagination(c *gin.Context) (page int, pageSize int) {
+	page = Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize = Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	} else if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	return page, pageSize
+}
@@ -1,30 +0,0 @@
-package utils
-
-import (
-	"fmt"
-	"os"
-
-	"gopkg.in/gomail.v2"
-)
-
-func SendEmail(to string, subject string, body string) error {
-	from := os.Getenv("SMTP_FROM")
-	username := os.Getenv("SMTP_USERNAME")
-	key := os.Getenv("SMTP_KEY")
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := Atoi(os.Getenv("SMTP_PORT"))
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", from)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
-
-	d := gomail.NewDialer(smtpHost, smtpPort, username, key)
-
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
-	}
-
-	return nil
-}
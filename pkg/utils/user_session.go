@@ -2,8 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,10 +11,8 @@ import (
 	"gorm.io/gorm"
 )
 
-var secret bool = false
-
-// Generate new user access_token and refresh_token
-func GenerateUserSession(c *gin.Context, userID uint64) error {
+// Generate new user access_token and refresh_token, authenticatedAt is when the user logged in
+func GenerateUserSession(c *gin.Context, db *gorm.DB, userID uint64, authenticatedAt time.Time) error {
 	var err error
 	secretKey, err := encryption.RandStringRunes(1024, true)
 	if err != nil {
@@ -26,13 +22,15 @@ func GenerateUserSession(c *gin.Context, userID uint64) error {
 		SessionID: encryption.GenerateID(),
 		SecretKey: secretKey,
 		UserID:    userID,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * time.Duration(CookieRefreshTokenExpires)),
+		ExpiresAt: time.Now().Add(CookieRefreshTokenExpires),
 		CreatedAt: time.Now(),
+
+		AuthenticatedAt: authenticatedAt,
 	}
 
 	// Check if a session with the same secretKey already exists
 	for {
-		_, result := queries.GetSessionQueueBySecretKey(session.SecretKey)
+		_, result := queries.GetSessionQueueBySecretKey(db, session.SecretKey)
 		if result.Error == gorm.ErrRecordNotFound {
 			break
 		} else if result.Error != nil {
@@ -49,31 +47,21 @@ func GenerateUserSession(c *gin.Context, userID uint64) error {
 	}
 
 	// Create the new session in the database
-	result := queries.CreateSessionQueue(session)
+	result := queries.CreateSessionQueue(db, session)
 	if result.Error != nil {
 		return result.Error
 	}
 
 	// Generate the access token
-	accessTokenExpiresAt := time.Now().Add(time.Minute * time.Duration(CookieAccessTokenExpires))
+	accessTokenExpiresAt := time.Now().Add(CookieAccessTokenExpires)
 	accessToken, err := encryption.GenerateNewJwtToken(userID, []string{}, accessTokenExpiresAt)
 	if err != nil {
 		return err
 	}
 
 	// Set the cookies
-	c.SetCookie("refresh_token", session.SecretKey, CookieRefreshTokenExpires*24*60*60, "", "", secret, true)
-	c.SetCookie("access_token", accessToken, CookieAccessTokenExpires*60, "/", "", secret, false)
+	c.SetCookie("refresh_token", session.SecretKey, int(CookieRefreshTokenExpires.Seconds()), "", "", SecureCookie, true)
+	c.SetCookie("access_token", accessToken, int(CookieAccessTokenExpires.Seconds()), "/", "", SecureCookie, false)
 
 	return nil
 }
-
-func init() {
-	baseURL := os.Getenv("BASE_URL")
-
-	if strings.HasPrefix(baseURL, "https://") {
-		secret = true
-	} else {
-		secret = false
-	}
-}
\ No newline at end of file
@@ -1,11 +1,11 @@
 package utils
 
 import (
+	"fmt"
 	"regexp"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
-	"github.com/instructhub/backend/pkg/logger"
 )
 
 var LangList = []string{"en", "es", "zh-tw", "zh-cn"}
@@ -35,11 +35,14 @@ var usernameValidator validator.Func = func(fl validator.FieldLevel) bool {
 	return matched
 }
 
-func init() {
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("lang", langLocalValidator)
-		v.RegisterValidation("username", usernameValidator)
-	} else {
-		logger.Log.Fatal("error registering validator")
+// RegisterValidators adds the custom validation tags to the gin binding validator
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("error registering validator")
 	}
+	if err := v.RegisterValidation("lang", langLocalValidator); err != nil {
+		return err
+	}
+	return v.RegisterValidation("username", usernameValidator)
 }
\ No newline at end of file
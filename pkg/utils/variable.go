@@ -3,30 +3,34 @@ package utils
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
+
+	"github.com/instructhub/backend/pkg/config"
 )
 
 var (
-	// Unit is days
-	CookieRefreshTokenExpires int
-	// Unit is minutes
-	CookieAccessTokenExpires int
-	BackendURL               string
-	FrontendURl              string
-	GiteaORGName             string
-	GiteaCommitEmail         string
+	CookieRefreshTokenExpires time.Duration
+	CookieAccessTokenExpires  time.Duration
+	BackendURL                string
+	FrontendURl               string
+	GiteaORGName              string
+	GiteaCommitEmail          string
+	// Cookies are only sent over HTTPS when the site is served with it
+	SecureCookie bool
 )
 
-// Init some usefil variables
-func init() {
-	GiteaORGName = os.Getenv("GITEA_ORG_NAME")
-	CookieRefreshTokenExpires = Atoi(os.Getenv("COOKIE_REFRESH_TOKEN_EXPIRES"))
-	CookieAccessTokenExpires = Atoi(os.Getenv("COOKIE_ACCESS_TOKEN_EXPIRES"))
-	BackendURL = fmt.Sprintf("%s/api/v%s", os.Getenv("BASE_URL"), os.Getenv("VERSION"))
-	FrontendURl = os.Getenv("BASE_URL")
-	GiteaCommitEmail = os.Getenv("GITEA_COMMIT_EMAIL")
+// LoadVariables sets some useful variables from the configuration
+func LoadVariables(cfg *config.Config) {
+	GiteaORGName = cfg.Gitea.OrgName
+	CookieRefreshTokenExpires = cfg.Auth.RefreshTokenExpires
+	CookieAccessTokenExpires = cfg.Auth.AccessTokenExpires
+	BackendURL = cfg.BackendURL()
+	FrontendURl = cfg.Server.BaseURL
+	GiteaCommitEmail = cfg.Gitea.CommitEmail
+	SecureCookie = strings.HasPrefix(FrontendURl, "https://")
 }
 
 // Magic bytes for different image formats